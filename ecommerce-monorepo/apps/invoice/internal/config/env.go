@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -18,10 +19,51 @@ type Config struct {
 	AWSKeyID     string
 	AWSSecretKey string
 
+	AdminAPIToken string
+
 	DBMaxConns        int32
 	DBMinConns        int32
 	DBMaxConnLifetime time.Duration
 	DBMaxConnIdleTime time.Duration
+
+	SubscribeMaxRetries     int
+	SubscribeRetryBaseDelay time.Duration
+	SubscribeRetryMaxDelay  time.Duration
+
+	OutboxPollInterval   time.Duration
+	OutboxBatchSize      int
+	OutboxConcurrency    int
+	OutboxMaxRetries     int
+	OutboxRetryBaseDelay time.Duration
+	OutboxRetryMaxDelay  time.Duration
+
+	OutboxRetention          time.Duration
+	OutboxRetentionInterval  time.Duration
+	OutboxRetentionBatchSize int
+	OutboxArchive            bool
+
+	InvoiceNumberPrefix  string
+	CreditNotePrefix     string
+	FiscalYearStartMonth time.Month
+	BusinessTimezone     *time.Location
+
+	BrandingFile      string
+	BrandLegalName    string
+	BrandAddress      []string
+	BrandTaxID        string
+	BrandGSTIN        string
+	BrandPhone        string
+	BrandEmail        string
+	BrandWebsite      string
+	BrandLogoPath     string
+	BrandPrimaryColor string
+	BrandTextColor    string
+	BrandFillColor    string
+	BrandFooterText   string
+
+	FontDir            string
+	FontDefaultFamily  string
+	FontLocaleFamilies map[string]string
 }
 
 func Load() (*Config, error) {
@@ -50,6 +92,8 @@ func Load() (*Config, error) {
 	cfg.AWSKeyID = getEnv("AWS_ACCESS_KEY_ID", "")
 	cfg.AWSSecretKey = getEnv("AWS_SECRET_ACCESS_KEY", "")
 
+	cfg.AdminAPIToken = getEnv("ADMIN_API_TOKEN", "")
+
 	maxConns, _ := getEnvInt("DB_MAX_CONNS", 10)
 	cfg.DBMaxConns = int32(maxConns)
 
@@ -59,6 +103,143 @@ func Load() (*Config, error) {
 	cfg.DBMaxConnLifetime = 1 * time.Hour
 	cfg.DBMaxConnIdleTime = 30 * time.Minute
 
+	cfg.SubscribeMaxRetries, err = getEnvInt("SUBSCRIBE_MAX_RETRIES", 5)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.SubscribeMaxRetries < 0 {
+		return nil, fmt.Errorf("SUBSCRIBE_MAX_RETRIES must not be negative, got %d", cfg.SubscribeMaxRetries)
+	}
+
+	cfg.SubscribeRetryBaseDelay, err = getEnvDuration("SUBSCRIBE_RETRY_BASE_DELAY", 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.SubscribeRetryBaseDelay <= 0 {
+		return nil, fmt.Errorf("SUBSCRIBE_RETRY_BASE_DELAY must be positive, got %s", cfg.SubscribeRetryBaseDelay)
+	}
+
+	cfg.SubscribeRetryMaxDelay, err = getEnvDuration("SUBSCRIBE_RETRY_MAX_DELAY", 10*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.SubscribeRetryMaxDelay < cfg.SubscribeRetryBaseDelay {
+		return nil, fmt.Errorf("SUBSCRIBE_RETRY_MAX_DELAY must be at least SUBSCRIBE_RETRY_BASE_DELAY (%s), got %s", cfg.SubscribeRetryBaseDelay, cfg.SubscribeRetryMaxDelay)
+	}
+
+	cfg.OutboxPollInterval, err = getEnvDuration("OUTBOX_POLL_INTERVAL", 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.OutboxPollInterval <= 0 {
+		return nil, fmt.Errorf("OUTBOX_POLL_INTERVAL must be positive, got %s", cfg.OutboxPollInterval)
+	}
+
+	cfg.OutboxBatchSize, err = getEnvInt("OUTBOX_BATCH_SIZE", 50)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.OutboxBatchSize <= 0 {
+		return nil, fmt.Errorf("OUTBOX_BATCH_SIZE must be positive, got %d", cfg.OutboxBatchSize)
+	}
+
+	cfg.OutboxConcurrency, err = getEnvInt("OUTBOX_CONCURRENCY", 8)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.OutboxMaxRetries, err = getEnvInt("OUTBOX_MAX_RETRIES", 10)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.OutboxMaxRetries < 0 {
+		return nil, fmt.Errorf("OUTBOX_MAX_RETRIES must not be negative, got %d", cfg.OutboxMaxRetries)
+	}
+
+	cfg.OutboxRetryBaseDelay, err = getEnvDuration("OUTBOX_RETRY_BASE_DELAY", 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.OutboxRetryBaseDelay <= 0 {
+		return nil, fmt.Errorf("OUTBOX_RETRY_BASE_DELAY must be positive, got %s", cfg.OutboxRetryBaseDelay)
+	}
+
+	cfg.OutboxRetryMaxDelay, err = getEnvDuration("OUTBOX_RETRY_MAX_DELAY", 30*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.OutboxRetryMaxDelay < cfg.OutboxRetryBaseDelay {
+		return nil, fmt.Errorf("OUTBOX_RETRY_MAX_DELAY must be at least OUTBOX_RETRY_BASE_DELAY (%s), got %s", cfg.OutboxRetryBaseDelay, cfg.OutboxRetryMaxDelay)
+	}
+
+	cfg.OutboxRetention, err = getEnvDuration("OUTBOX_RETENTION", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.OutboxRetentionInterval, err = getEnvDuration("OUTBOX_RETENTION_INTERVAL", 1*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.OutboxRetentionInterval <= 0 {
+		return nil, fmt.Errorf("OUTBOX_RETENTION_INTERVAL must be positive, got %s", cfg.OutboxRetentionInterval)
+	}
+
+	cfg.OutboxRetentionBatchSize, err = getEnvInt("OUTBOX_RETENTION_BATCH_SIZE", 1000)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.OutboxRetentionBatchSize <= 0 {
+		return nil, fmt.Errorf("OUTBOX_RETENTION_BATCH_SIZE must be positive, got %d", cfg.OutboxRetentionBatchSize)
+	}
+
+	cfg.OutboxArchive, err = getEnvBool("OUTBOX_ARCHIVE", true)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.InvoiceNumberPrefix = getEnv("INVOICE_NUMBER_PREFIX", "INV")
+	cfg.CreditNotePrefix = getEnv("CREDIT_NOTE_NUMBER_PREFIX", "CN")
+	if cfg.CreditNotePrefix == cfg.InvoiceNumberPrefix {
+		return nil, fmt.Errorf("CREDIT_NOTE_NUMBER_PREFIX must differ from INVOICE_NUMBER_PREFIX, both are %q", cfg.CreditNotePrefix)
+	}
+
+	startMonth, err := getEnvInt("FISCAL_YEAR_START_MONTH", 1)
+	if err != nil {
+		return nil, err
+	}
+	if startMonth < 1 || startMonth > 12 {
+		return nil, fmt.Errorf("FISCAL_YEAR_START_MONTH must be between 1 and 12, got %d", startMonth)
+	}
+	cfg.FiscalYearStartMonth = time.Month(startMonth)
+
+	cfg.BusinessTimezone, err = time.LoadLocation(getEnv("BUSINESS_TIMEZONE", "UTC"))
+	if err != nil {
+		return nil, fmt.Errorf("BUSINESS_TIMEZONE: %w", err)
+	}
+
+	cfg.BrandingFile = getEnv("BRANDING_FILE", "")
+	cfg.BrandLegalName = getEnv("BRAND_LEGAL_NAME", "")
+	cfg.BrandAddress = getEnvList("BRAND_ADDRESS", "|")
+	cfg.BrandTaxID = getEnv("BRAND_TAX_ID", "")
+	cfg.BrandGSTIN = getEnv("BRAND_GSTIN", "")
+	cfg.BrandPhone = getEnv("BRAND_PHONE", "")
+	cfg.BrandEmail = getEnv("BRAND_EMAIL", "")
+	cfg.BrandWebsite = getEnv("BRAND_WEBSITE", "")
+	cfg.BrandLogoPath = getEnv("BRAND_LOGO_PATH", "")
+	cfg.BrandPrimaryColor = getEnv("BRAND_PRIMARY_COLOR", "#9333EA")
+	cfg.BrandTextColor = getEnv("BRAND_TEXT_COLOR", "#1F2937")
+	cfg.BrandFillColor = getEnv("BRAND_FILL_COLOR", "#F3F4F6")
+	cfg.BrandFooterText = getEnv("BRAND_FOOTER_TEXT", "")
+
+	cfg.FontDir = getEnv("FONT_DIR", "")
+	cfg.FontDefaultFamily = getEnv("FONT_DEFAULT_FAMILY", "DejaVuSansCondensed")
+
+	cfg.FontLocaleFamilies, err = getEnvMap("FONT_LOCALE_FAMILIES")
+	if err != nil {
+		return nil, err
+	}
+
 	if cfg.AWSBucket == "" || cfg.AWSKeyID == "" || cfg.AWSSecretKey == "" {
 		return nil, fmt.Errorf("AWS configuration is incomplete")
 	}
@@ -73,6 +254,33 @@ func getEnv(key string, fallback string) string {
 	return fallback
 }
 
+func getEnvList(key string, sep string) []string {
+	value, exists := os.LookupEnv(key)
+	if !exists || value == "" {
+		return nil
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func getEnvMap(key string) (map[string]string, error) {
+	pairs := make(map[string]string)
+	for _, item := range getEnvList(key, ",") {
+		k, v, found := strings.Cut(item, "=")
+		if !found || strings.TrimSpace(k) == "" || strings.TrimSpace(v) == "" {
+			return nil, fmt.Errorf("%s must be a comma separated list of key=value pairs", key)
+		}
+		pairs[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return pairs, nil
+}
+
 func getEnvInt(key string, fallback int) (int, error) {
 	if value, exists := os.LookupEnv(key); exists {
 		parsed, err := strconv.Atoi(value)
@@ -84,6 +292,28 @@ func getEnvInt(key string, fallback int) (int, error) {
 	return fallback, nil
 }
 
+func getEnvBool(key string, fallback bool) (bool, error) {
+	if value, exists := os.LookupEnv(key); exists {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return false, fmt.Errorf("%s must be a boolean", key)
+		}
+		return parsed, nil
+	}
+	return fallback, nil
+}
+
+func getEnvDuration(key string, fallback time.Duration) (time.Duration, error) {
+	if value, exists := os.LookupEnv(key); exists {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("%s must be a duration", key)
+		}
+		return parsed, nil
+	}
+	return fallback, nil
+}
+
 func getRequiredEnv(key string) (string, error) {
 	value, exists := os.LookupEnv(key)
 	if !exists || value == "" {
@@ -3,30 +3,130 @@ package eventbus
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"sync"
+	"time"
 
+	"github.com/google/uuid"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
+var (
+	ErrNotConnected = errors.New("eventbus: not connected to broker")
+	ErrUnroutable   = errors.New("eventbus: message returned as unroutable")
+	ErrNacked       = errors.New("eventbus: message nacked by broker")
+)
+
+const (
+	reconnectBaseDelay = 1 * time.Second
+	reconnectMaxDelay  = 30 * time.Second
+	workerCount        = 50
+	confirmTimeout     = 5 * time.Second
+)
+
+type Metadata struct {
+	MessageID     string
+	CorrelationID string
+	AggregateID   string
+	SchemaVersion int
+	Timestamp     time.Time
+}
+
+type subscription struct {
+	queueName   string
+	routingKeys []string
+	handler     func([]byte) error
+	policy      RetryPolicy
+}
+
 type EventBus struct {
-	conn     *amqp.Connection
-	channel  *amqp.Channel
+	url      string
 	exchange string
+
+	mu             sync.RWMutex
+	conn           *amqp.Connection
+	channel        *amqp.Channel
+	publishChannel *amqp.Channel
+	returns        *returnTracker
+	subscriptions  []subscription
+
+	publishMu sync.Mutex
+
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
 func NewEventBus(url, exchange string) (*EventBus, error) {
-	conn, err := amqp.Dial(url)
-	if err != nil {
+	e := &EventBus{
+		url:      url,
+		exchange: exchange,
+		done:     make(chan struct{}),
+	}
+
+	if err := e.connect(); err != nil {
 		return nil, err
 	}
 
+	go e.supervise()
+
+	return e, nil
+}
+
+func (e *EventBus) connect() error {
+	conn, err := amqp.Dial(e.url)
+	if err != nil {
+		return err
+	}
+
 	ch, err := conn.Channel()
 	if err != nil {
-		return nil, err
+		conn.Close()
+		return err
+	}
+
+	if err := e.declareExchanges(ch); err != nil {
+		conn.Close()
+		return err
+	}
+
+	err = ch.Qos(
+		100,
+		0,
+		false,
+	)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	pubCh, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	if err := pubCh.Confirm(false); err != nil {
+		conn.Close()
+		return err
 	}
+	returns := newReturnTracker(pubCh.NotifyReturn(make(chan amqp.Return, 64)))
 
-	err = ch.ExchangeDeclare(
-		exchange,
+	e.mu.Lock()
+	e.conn = conn
+	e.channel = ch
+	e.publishChannel = pubCh
+	e.returns = returns
+	e.mu.Unlock()
+
+	log.Printf("[RabbitMQ] Connected to exchange: %s", e.exchange)
+	return nil
+}
+
+func (e *EventBus) declareExchanges(ch *amqp.Channel) error {
+	err := ch.ExchangeDeclare(
+		e.exchange,
 		"topic",
 		true,
 		false,
@@ -35,15 +135,104 @@ func NewEventBus(url, exchange string) (*EventBus, error) {
 		nil,
 	)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	log.Printf("[RabbitMQ] Connected to exchange: %s", exchange)
-	return &EventBus{
-		conn:     conn,
-		channel:  ch,
-		exchange: exchange,
-	}, nil
+	return ch.ExchangeDeclare(
+		deadLetterExchangeName(e.exchange),
+		"direct",
+		true,
+		false,
+		false,
+		false,
+		nil,
+	)
+}
+
+func (e *EventBus) supervise() {
+	for {
+		e.mu.RLock()
+		connClosed := e.conn.NotifyClose(make(chan *amqp.Error, 1))
+		chanClosed := e.channel.NotifyClose(make(chan *amqp.Error, 1))
+		pubClosed := e.publishChannel.NotifyClose(make(chan *amqp.Error, 1))
+		e.mu.RUnlock()
+
+		var cause *amqp.Error
+		select {
+		case <-e.done:
+			return
+		case cause = <-connClosed:
+		case cause = <-chanClosed:
+		case cause = <-pubClosed:
+		}
+
+		log.Printf("[RabbitMQ] Connection lost: %v", cause)
+
+		e.mu.Lock()
+		e.channel = nil
+		e.publishChannel = nil
+		if e.conn != nil {
+			e.conn.Close()
+		}
+		e.conn = nil
+		e.mu.Unlock()
+
+		if !e.reconnect() {
+			return
+		}
+	}
+}
+
+func (e *EventBus) reconnect() bool {
+	delay := reconnectBaseDelay
+	for {
+		select {
+		case <-e.done:
+			return false
+		case <-time.After(delay):
+		}
+
+		if err := e.connect(); err != nil {
+			log.Printf("[RabbitMQ] Reconnect failed, retrying in %s: %v", delay, err)
+			delay = min(delay*2, reconnectMaxDelay)
+			continue
+		}
+
+		if err := e.resubscribe(); err != nil {
+			log.Printf("[RabbitMQ] Resubscribe failed, reconnecting: %v", err)
+			e.mu.Lock()
+			e.conn.Close()
+			e.mu.Unlock()
+			delay = min(delay*2, reconnectMaxDelay)
+			continue
+		}
+
+		log.Println("[RabbitMQ] Reconnected")
+		return true
+	}
+}
+
+func (e *EventBus) resubscribe() error {
+	e.mu.RLock()
+	subs := append([]subscription(nil), e.subscriptions...)
+	e.mu.RUnlock()
+
+	for _, sub := range subs {
+		if err := e.consume(sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *EventBus) currentChannel() (*amqp.Channel, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.channel == nil || e.channel.IsClosed() {
+		return nil, ErrNotConnected
+	}
+	return e.channel, nil
 }
 
 func (e *EventBus) Publish(ctx context.Context, routingKey string, event any) error {
@@ -52,53 +241,132 @@ func (e *EventBus) Publish(ctx context.Context, routingKey string, event any) er
 		return err
 	}
 
-	return e.channel.PublishWithContext(
-		ctx,
-		e.exchange,
-		routingKey,
-		false,
-		false,
-		amqp.Publishing{
-			ContentType:  "application/json",
-			Body:         body,
-			DeliveryMode: amqp.Persistent,
+	return e.publish(ctx, e.exchange, routingKey, amqp.Publishing{
+		ContentType:  "application/json",
+		Body:         body,
+		DeliveryMode: amqp.Persistent,
+	})
+}
+
+func (e *EventBus) PublishWithMetadata(ctx context.Context, routingKey string, event any, meta Metadata) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return e.publish(ctx, e.exchange, routingKey, amqp.Publishing{
+		ContentType:   "application/json",
+		Body:          body,
+		DeliveryMode:  amqp.Persistent,
+		MessageId:     meta.MessageID,
+		CorrelationId: meta.CorrelationID,
+		Timestamp:     meta.Timestamp,
+		Type:          routingKey,
+		Headers: amqp.Table{
+			"x-aggregate-id":   meta.AggregateID,
+			"x-correlation-id": meta.CorrelationID,
+			"x-schema-version": int32(meta.SchemaVersion),
 		},
-	)
+	})
 }
 
-func (e *EventBus) Subscribe(queueName string, routingKeys []string, handler func([]byte) error) error {
-	q, err := e.channel.QueueDeclare(queueName, true, false, false, false, nil)
+func (e *EventBus) publish(ctx context.Context, exchange, routingKey string, msg amqp.Publishing) error {
+	if msg.MessageId == "" {
+		msg.MessageId = uuid.New().String()
+	}
+
+	e.mu.RLock()
+	ch, returns := e.publishChannel, e.returns
+	e.mu.RUnlock()
+
+	if ch == nil || ch.IsClosed() {
+		return ErrNotConnected
+	}
+
+	returns.track(msg.MessageId)
+	defer returns.untrack(msg.MessageId)
+
+	ctx, cancel := context.WithTimeout(ctx, confirmTimeout)
+	defer cancel()
+
+	e.publishMu.Lock()
+	confirm, err := ch.PublishWithDeferredConfirmWithContext(ctx, exchange, routingKey, true, false, msg)
+	e.publishMu.Unlock()
 	if err != nil {
 		return err
 	}
 
-	for _, key := range routingKeys {
-		if err := e.channel.QueueBind(q.Name, key, e.exchange, false, nil); err != nil {
+	acked, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("eventbus: waiting for confirm on %s: %w", routingKey, err)
+	}
+
+	ret, returned, err := returns.take(ctx, msg.MessageId)
+	if err != nil {
+		return fmt.Errorf("eventbus: waiting for returns on %s: %w", routingKey, err)
+	}
+	if returned {
+		return fmt.Errorf("%w: %s (%d %s)", ErrUnroutable, ret.RoutingKey, ret.ReplyCode, ret.ReplyText)
+	}
+
+	if !acked {
+		return fmt.Errorf("%w: %s", ErrNacked, routingKey)
+	}
+
+	return nil
+}
+
+func (e *EventBus) Subscribe(queueName string, routingKeys []string, handler func([]byte) error, policy RetryPolicy) error {
+	sub := subscription{
+		queueName:   queueName,
+		routingKeys: routingKeys,
+		handler:     handler,
+		policy:      policy,
+	}
+
+	if err := e.consume(sub); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	e.subscriptions = append(e.subscriptions, sub)
+	e.mu.Unlock()
+
+	return nil
+}
+
+func (e *EventBus) consume(sub subscription) error {
+	ch, err := e.currentChannel()
+	if err != nil {
+		return err
+	}
+
+	q, err := ch.QueueDeclare(sub.queueName, true, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range sub.routingKeys {
+		if err := ch.QueueBind(q.Name, key, e.exchange, false, nil); err != nil {
 			return err
 		}
 	}
 
-	err = e.channel.Qos(
-		100,
-		0,
-		false,
-	)
-	if err != nil {
+	if err := e.declareRetryTopology(ch, q.Name, sub.policy); err != nil {
 		return err
 	}
 
-	msgs, err := e.channel.Consume(q.Name, "", false, false, false, false, nil)
+	msgs, err := ch.Consume(q.Name, "", false, false, false, false, nil)
 	if err != nil {
 		return err
 	}
 
-	workerCount := 50
 	for i := 1; i <= workerCount; i++ {
 		go func(workerID int) {
 			for msg := range msgs {
-				if err := handler(msg.Body); err != nil {
-					log.Printf("[Worker %d] Processing Failed [%s]: %v", workerID, msg.RoutingKey, err)
-					msg.Nack(false, true)
+				if err := sub.handler(msg.Body); err != nil {
+					log.Printf("[Worker %d] Processing Failed [%s]: %v", workerID, originalRoutingKey(msg), err)
+					e.handleFailure(q.Name, sub.policy, msg, err)
 					continue
 				}
 				msg.Ack(false)
@@ -109,16 +377,85 @@ func (e *EventBus) Subscribe(queueName string, routingKeys []string, handler fun
 	return nil
 }
 
-func (e *EventBus) Close() {
-	if e.channel != nil {
-		e.channel.Close()
+func (e *EventBus) declareRetryTopology(ch *amqp.Channel, queueName string, policy RetryPolicy) error {
+	dlq := deadLetterQueueName(queueName)
+	if _, err := ch.QueueDeclare(dlq, true, false, false, false, nil); err != nil {
+		return err
+	}
+	if err := ch.QueueBind(dlq, queueName, deadLetterExchangeName(e.exchange), false, nil); err != nil {
+		return err
 	}
-	if e.conn != nil {
-		e.conn.Close()
+
+	for attempt := 1; attempt <= policy.MaxRetries; attempt++ {
+		delay := policy.Delay(attempt)
+		args := amqp.Table{
+			"x-message-ttl":             delay.Milliseconds(),
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": queueName,
+		}
+		if _, err := ch.QueueDeclare(retryQueueName(queueName, attempt, delay), true, false, false, false, args); err != nil {
+			return err
+		}
 	}
-	log.Println("[RabbitMQ] Connection closed")
+
+	return nil
+}
+
+func (e *EventBus) handleFailure(queueName string, policy RetryPolicy, msg amqp.Delivery, cause error) {
+	attempt := retryCount(msg.Headers) + 1
+
+	exchange := ""
+	routingKey := retryQueueName(queueName, attempt, policy.Delay(attempt))
+	if attempt > policy.MaxRetries {
+		exchange = deadLetterExchangeName(e.exchange)
+		routingKey = queueName
+	}
+
+	err := e.publish(context.Background(), exchange, routingKey, amqp.Publishing{
+		ContentType:   msg.ContentType,
+		Body:          msg.Body,
+		DeliveryMode:  amqp.Persistent,
+		MessageId:     msg.MessageId,
+		CorrelationId: msg.CorrelationId,
+		Timestamp:     msg.Timestamp,
+		Type:          msg.Type,
+		Headers:       failureHeaders(msg, attempt, cause),
+	})
+	if err != nil {
+		log.Printf("[RabbitMQ] Failed to reroute message from %s: %v", queueName, err)
+		msg.Nack(false, true)
+		return
+	}
+
+	if attempt > policy.MaxRetries {
+		log.Printf("[RabbitMQ] Message dead-lettered to %s after %d attempts", deadLetterQueueName(queueName), attempt)
+	}
+	msg.Ack(false)
+}
+
+func (e *EventBus) Close() {
+	e.closeOnce.Do(func() {
+		close(e.done)
+
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		if e.publishChannel != nil {
+			e.publishChannel.Close()
+		}
+		if e.channel != nil {
+			e.channel.Close()
+		}
+		if e.conn != nil {
+			e.conn.Close()
+		}
+		log.Println("[RabbitMQ] Connection closed")
+	})
 }
 
 func (e *EventBus) IsHealthy() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
 	return e.conn != nil && !e.conn.IsClosed()
 }
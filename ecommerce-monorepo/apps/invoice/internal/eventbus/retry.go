@@ -0,0 +1,80 @@
+package eventbus
+
+import (
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	headerRetryCount         = "x-retry-count"
+	headerLastError          = "x-last-error"
+	headerOriginalRoutingKey = "x-original-routing-key"
+	headerFailedAt           = "x-failed-at"
+)
+
+type RetryPolicy struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// retryQueueName includes the delay because RabbitMQ refuses to redeclare a
+// queue with a different x-message-ttl; a new delay gets a new queue.
+func retryQueueName(queueName string, attempt int, delay time.Duration) string {
+	return fmt.Sprintf("%s.retry.%d.%dms", queueName, attempt, delay.Milliseconds())
+}
+
+func deadLetterQueueName(queueName string) string {
+	return queueName + ".dlq"
+}
+
+func deadLetterExchangeName(exchange string) string {
+	return exchange + ".dlx"
+}
+
+func retryCount(headers amqp.Table) int {
+	switch v := headers[headerRetryCount].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
+
+func originalRoutingKey(msg amqp.Delivery) string {
+	if key, ok := msg.Headers[headerOriginalRoutingKey].(string); ok && key != "" {
+		return key
+	}
+	return msg.RoutingKey
+}
+
+func failureHeaders(msg amqp.Delivery, attempt int, cause error) amqp.Table {
+	headers := amqp.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[headerRetryCount] = int32(attempt)
+	headers[headerLastError] = cause.Error()
+	headers[headerOriginalRoutingKey] = originalRoutingKey(msg)
+	headers[headerFailedAt] = time.Now().UTC().Format(time.RFC3339)
+	return headers
+}
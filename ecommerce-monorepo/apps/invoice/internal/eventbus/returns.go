@@ -0,0 +1,112 @@
+package eventbus
+
+import (
+	"context"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// returnTracker drains basic.return frames for one publish channel in its own
+// goroutine, so amqp091's connection reader never blocks on a full returns
+// channel, and keeps the ones whose MessageId is still being published.
+type returnTracker struct {
+	mu       sync.Mutex
+	inFlight map[string]int
+	returned map[string]amqp.Return
+
+	flush chan chan struct{}
+	done  chan struct{}
+}
+
+func newReturnTracker(returns <-chan amqp.Return) *returnTracker {
+	t := &returnTracker{
+		inFlight: make(map[string]int),
+		returned: make(map[string]amqp.Return),
+		flush:    make(chan chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go t.collect(returns)
+	return t
+}
+
+func (t *returnTracker) collect(returns <-chan amqp.Return) {
+	defer close(t.done)
+
+	for {
+		select {
+		case ret, ok := <-returns:
+			if !ok {
+				return
+			}
+			t.store(ret)
+		case flushed := <-t.flush:
+			for drained := false; !drained; {
+				select {
+				case ret, ok := <-returns:
+					if !ok {
+						close(flushed)
+						return
+					}
+					t.store(ret)
+				default:
+					drained = true
+				}
+			}
+			close(flushed)
+		}
+	}
+}
+
+func (t *returnTracker) store(ret amqp.Return) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.inFlight[ret.MessageId] > 0 {
+		t.returned[ret.MessageId] = ret
+	}
+}
+
+func (t *returnTracker) track(messageID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.inFlight[messageID]++
+}
+
+func (t *returnTracker) untrack(messageID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.inFlight[messageID]--; t.inFlight[messageID] <= 0 {
+		delete(t.inFlight, messageID)
+		delete(t.returned, messageID)
+	}
+}
+
+// take reports whether messageID was returned. The broker sends basic.return
+// before the confirm of the same message and amqp091 hands it to the returns
+// channel before resolving the confirm, so after a confirm one flush of the
+// collector is enough to have seen it.
+func (t *returnTracker) take(ctx context.Context, messageID string) (amqp.Return, bool, error) {
+	flushed := make(chan struct{})
+	select {
+	case t.flush <- flushed:
+		select {
+		case <-flushed:
+		case <-t.done:
+		case <-ctx.Done():
+			return amqp.Return{}, false, ctx.Err()
+		}
+	case <-t.done:
+	case <-ctx.Done():
+		return amqp.Return{}, false, ctx.Err()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ret, ok := t.returned[messageID]
+	delete(t.returned, messageID)
+	return ret, ok, nil
+}
@@ -0,0 +1,66 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func TestReturnTrackerDoesNotBlockTheSender(t *testing.T) {
+	returns := make(chan amqp.Return, 1)
+	tracker := newReturnTracker(returns)
+	defer close(returns)
+
+	const n = 200
+	for i := 0; i < n; i++ {
+		tracker.track(fmt.Sprintf("msg-%d", i))
+	}
+
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		for i := 0; i < n; i++ {
+			returns <- amqp.Return{MessageId: fmt.Sprintf("msg-%d", i), ReplyCode: 312}
+		}
+		returns <- amqp.Return{MessageId: "not-tracked", ReplyCode: 312}
+	}()
+
+	select {
+	case <-sent:
+	case <-time.After(5 * time.Second):
+		t.Fatal("sending returns blocked")
+	}
+
+	ctx := context.Background()
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("msg-%d", i)
+		if _, ok, err := tracker.take(ctx, id); err != nil || !ok {
+			t.Fatalf("%s: returned=%v err=%v, want returned", id, ok, err)
+		}
+		tracker.untrack(id)
+	}
+
+	if _, ok, err := tracker.take(ctx, "not-tracked"); err != nil || ok {
+		t.Fatalf("untracked message: returned=%v err=%v", ok, err)
+	}
+}
+
+func TestReturnTrackerSeesReturnSentBeforeTake(t *testing.T) {
+	returns := make(chan amqp.Return, 64)
+	tracker := newReturnTracker(returns)
+	defer close(returns)
+
+	for i := 0; i < 1000; i++ {
+		id := fmt.Sprintf("msg-%d", i)
+		tracker.track(id)
+		returns <- amqp.Return{MessageId: id}
+
+		if _, ok, err := tracker.take(context.Background(), id); err != nil || !ok {
+			t.Fatalf("%s: returned=%v err=%v, want returned", id, ok, err)
+		}
+		tracker.untrack(id)
+	}
+}
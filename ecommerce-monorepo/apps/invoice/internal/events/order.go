@@ -1,6 +1,10 @@
 package events
 
-import "time"
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 type Address struct {
 	ID          string `json:"id"`
@@ -13,24 +17,31 @@ type Address struct {
 	PhoneNumber string `json:"phoneNumber"`
 }
 
+type OrderItem struct {
+	ProductID string          `json:"productId"`
+	Name      string          `json:"name"`
+	Price     decimal.Decimal `json:"price"`
+	Quantity  int             `json:"quantity"`
+	HSNCode   string          `json:"hsnCode"`
+	TaxRate   decimal.Decimal `json:"taxRate"`
+}
+
 type OrderPaidEvent struct {
 	EventName string `json:"eventType"`
 	Data      struct {
-		OrderID     string  `json:"orderId"`
-		UserID      string  `json:"userId"`
-		UserEmail   string  `json:"userEmail"`
-		UserName    string  `json:"userName"`
-		TotalAmount float64 `json:"totalAmount"`
-		Subtotal    float64 `json:"subtotal"`
-		TaxedAmount float64 `json:"taxedAmount"`
-		PaymentID   string  `json:"paymentId"`
-
-		Items []struct {
-			ProductID string  `json:"productId"`
-			Name      string  `json:"name"`
-			Price     float64 `json:"price"`
-			Quantity  int     `json:"quantity"`
-		} `json:"items"`
+		OrderID     string          `json:"orderId"`
+		UserID      string          `json:"userId"`
+		UserEmail   string          `json:"userEmail"`
+		UserName    string          `json:"userName"`
+		TotalAmount decimal.Decimal `json:"totalAmount"`
+		Subtotal    decimal.Decimal `json:"subtotal"`
+		TaxedAmount decimal.Decimal `json:"taxedAmount"`
+		PaymentID   string          `json:"paymentId"`
+		Currency    string          `json:"currency"`
+		Locale      string          `json:"locale"`
+		BuyerGSTIN  string          `json:"buyerGstin"`
+
+		Items []OrderItem `json:"items"`
 
 		ShippingAddress Address `json:"shippingAddress"`
 		BillingAddress  Address `json:"billingAddress"`
@@ -38,3 +49,28 @@ type OrderPaidEvent struct {
 		CreatedAt time.Time `json:"createdAt"`
 	} `json:"data"`
 }
+
+type OrderCancelledEvent struct {
+	EventName string `json:"eventType"`
+	Data      struct {
+		OrderID string `json:"orderId"`
+		UserID  string `json:"userId"`
+		Reason  string `json:"reason"`
+
+		CancelledAt time.Time `json:"cancelledAt"`
+	} `json:"data"`
+}
+
+type OrderRefundedEvent struct {
+	EventName string `json:"eventType"`
+	Data      struct {
+		OrderID  string          `json:"orderId"`
+		UserID   string          `json:"userId"`
+		RefundID string          `json:"refundId"`
+		Amount   decimal.Decimal `json:"amount"`
+		Reason   string          `json:"reason"`
+		Items    []OrderItem     `json:"items"`
+
+		CreatedAt time.Time `json:"createdAt"`
+	} `json:"data"`
+}
@@ -0,0 +1,108 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository struct {
+	db    *pgxpool.Pool
+	lease time.Duration
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{
+		db:    db,
+		lease: 2 * time.Minute,
+	}
+}
+
+type Status string
+
+const (
+	StatusProcessing Status = "PROCESSING"
+	StatusCompleted  Status = "COMPLETED"
+)
+
+// ErrLeaseHeld means another consumer holds a live lease on the message; the
+// caller should leave it unacked so it is redelivered after the lease expires.
+var ErrLeaseHeld = errors.New("inbox: message is being processed elsewhere")
+
+// Claim takes the processing lease on messageID and returns StatusProcessing,
+// or StatusCompleted when the message was already handled and can be acked.
+// A message whose lease is still held by someone else yields ErrLeaseHeld.
+func (r *Repository) Claim(ctx context.Context, messageID, eventType string) (Status, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO inbox_messages (message_id, event_type, status, locked_until)
+		VALUES ($1, $2, 'PROCESSING', NOW() + make_interval(secs => $3))
+		ON CONFLICT (message_id) DO UPDATE
+		SET attempts = inbox_messages.attempts + 1,
+			locked_until = EXCLUDED.locked_until
+		WHERE inbox_messages.status = 'PROCESSING'
+			AND inbox_messages.locked_until < NOW()
+		RETURNING message_id
+	`
+
+	var claimed string
+	err = tx.QueryRow(ctx, query, messageID, eventType, r.lease.Seconds()).Scan(&claimed)
+	if errors.Is(err, pgx.ErrNoRows) {
+		var status Status
+		err := tx.QueryRow(ctx,
+			`SELECT status FROM inbox_messages WHERE message_id = $1`,
+			messageID,
+		).Scan(&status)
+		if err != nil {
+			return "", err
+		}
+		if status == StatusCompleted {
+			return StatusCompleted, nil
+		}
+		return status, ErrLeaseHeld
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return StatusProcessing, tx.Commit(ctx)
+}
+
+func (r *Repository) Complete(ctx context.Context, tx pgx.Tx, messageID string) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE inbox_messages
+		SET status = 'COMPLETED', locked_until = NULL, processed_at = NOW()
+		WHERE message_id = $1
+	`, messageID)
+	return err
+}
+
+func (r *Repository) MarkCompleted(ctx context.Context, messageID string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := r.Complete(ctx, tx, messageID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *Repository) Release(ctx context.Context, messageID string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE inbox_messages
+		SET locked_until = NOW()
+		WHERE message_id = $1 AND status = 'PROCESSING'
+	`, messageID)
+	return err
+}
@@ -0,0 +1,42 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tomarrohitt/invoice-go/internal/testdb"
+)
+
+func TestClaimDoesNotAckMessageUnderLiveLease(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	repo := NewRepository(db)
+
+	status, err := repo.Claim(ctx, "msg-1", "order.paid")
+	if err != nil || status != StatusProcessing {
+		t.Fatalf("first claim: status=%q err=%v", status, err)
+	}
+
+	// The first holder crashed without releasing; the redelivery must be
+	// retried, not acked as a duplicate.
+	if _, err := repo.Claim(ctx, "msg-1", "order.paid"); !errors.Is(err, ErrLeaseHeld) {
+		t.Fatalf("claim under live lease: err=%v, want ErrLeaseHeld", err)
+	}
+
+	if err := repo.Release(ctx, "msg-1"); err != nil {
+		t.Fatal(err)
+	}
+	status, err = repo.Claim(ctx, "msg-1", "order.paid")
+	if err != nil || status != StatusProcessing {
+		t.Fatalf("claim after release: status=%q err=%v", status, err)
+	}
+
+	if err := repo.MarkCompleted(ctx, "msg-1"); err != nil {
+		t.Fatal(err)
+	}
+	status, err = repo.Claim(ctx, "msg-1", "order.paid")
+	if err != nil || status != StatusCompleted {
+		t.Fatalf("claim after completion: status=%q err=%v", status, err)
+	}
+}
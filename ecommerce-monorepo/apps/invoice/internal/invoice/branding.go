@@ -0,0 +1,181 @@
+package invoice
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+const maxBrandingAddressLines = 3
+
+// maxBrandingContactLines keeps the header contact block above the
+// Bill To panel, which starts at y=55.
+const maxBrandingContactLines = 6
+
+type Branding struct {
+	LegalName    string   `json:"legalName"`
+	Address      []string `json:"address"`
+	TaxID        string   `json:"taxId"`
+	GSTIN        string   `json:"gstin"`
+	Phone        string   `json:"phone"`
+	Email        string   `json:"email"`
+	Website      string   `json:"website"`
+	LogoPath     string   `json:"logoPath"`
+	PrimaryColor string   `json:"primaryColor"`
+	TextColor    string   `json:"textColor"`
+	FillColor    string   `json:"fillColor"`
+	FooterText   string   `json:"footerText"`
+
+	primary  []int
+	text     []int
+	fill     []int
+	logo     []byte
+	logoType string
+}
+
+func LoadBranding(path string, base Branding) (Branding, error) {
+	b := base
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Branding{}, fmt.Errorf("read branding file: %w", err)
+		}
+		if err := json.Unmarshal(data, &b); err != nil {
+			return Branding{}, fmt.Errorf("parse branding file %s: %w", path, err)
+		}
+	}
+
+	if err := b.validate(); err != nil {
+		return Branding{}, err
+	}
+
+	if b.LogoPath != "" {
+		logo, err := os.ReadFile(b.LogoPath)
+		if err != nil {
+			return Branding{}, fmt.Errorf("read branding logo: %w", err)
+		}
+		if err := b.checkLogo(logo); err != nil {
+			return Branding{}, err
+		}
+		b.logo = logo
+	}
+
+	return b, nil
+}
+
+func (b *Branding) validate() error {
+	var errs []string
+
+	if strings.TrimSpace(b.LegalName) == "" {
+		errs = append(errs, "legal name is required")
+	}
+	if !strings.Contains(b.Email, "@") {
+		errs = append(errs, fmt.Sprintf("contact email %q is invalid", b.Email))
+	}
+	if len(b.Address) > maxBrandingAddressLines {
+		errs = append(errs, fmt.Sprintf("address has %d lines, at most %d are allowed", len(b.Address), maxBrandingAddressLines))
+	}
+	if b.GSTIN != "" && !validGSTIN(b.GSTIN) {
+		errs = append(errs, fmt.Sprintf("GSTIN %q is invalid", b.GSTIN))
+	}
+	if n := b.contactLineCount(); n > maxBrandingContactLines {
+		errs = append(errs, fmt.Sprintf("address, tax ID, GSTIN, phone and email take %d header lines, at most %d fit", n, maxBrandingContactLines))
+	}
+
+	var err error
+	if b.primary, err = parseHexColor(b.PrimaryColor); err != nil {
+		errs = append(errs, "primary color: "+err.Error())
+	}
+	if b.text, err = parseHexColor(b.TextColor); err != nil {
+		errs = append(errs, "text color: "+err.Error())
+	}
+	if b.fill, err = parseHexColor(b.FillColor); err != nil {
+		errs = append(errs, "fill color: "+err.Error())
+	}
+
+	if b.LogoPath != "" {
+		switch strings.ToLower(filepath.Ext(b.LogoPath)) {
+		case ".png":
+			b.logoType = "PNG"
+		case ".jpg", ".jpeg":
+			b.logoType = "JPG"
+		default:
+			errs = append(errs, fmt.Sprintf("logo %s must be a PNG or JPEG image", b.LogoPath))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid branding: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// checkLogo decodes the logo and registers it with a scratch PDF, so a corrupt
+// or mislabelled image fails startup instead of every rendered invoice.
+func (b *Branding) checkLogo(logo []byte) error {
+	_, format, err := image.DecodeConfig(bytes.NewReader(logo))
+	if err != nil {
+		return fmt.Errorf("decode branding logo %s: %w", b.LogoPath, err)
+	}
+	if want := map[string]string{"PNG": "png", "JPG": "jpeg"}[b.logoType]; format != want {
+		return fmt.Errorf("branding logo %s is a %s image, not %s", b.LogoPath, format, want)
+	}
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.RegisterImageOptionsReader("logo", gofpdf.ImageOptions{ImageType: b.logoType}, bytes.NewReader(logo))
+	if err := pdf.Error(); err != nil {
+		return fmt.Errorf("load branding logo %s: %w", b.LogoPath, err)
+	}
+	return nil
+}
+
+func parseHexColor(s string) ([]int, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(hex) != 6 {
+		return nil, fmt.Errorf("%q is not a #RRGGBB value", s)
+	}
+
+	rgb := make([]int, 3)
+	for i := range rgb {
+		v, err := strconv.ParseUint(hex[i*2:i*2+2], 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a #RRGGBB value", s)
+		}
+		rgb[i] = int(v)
+	}
+	return rgb, nil
+}
+
+func (b Branding) contactLineCount() int {
+	n := len(b.Address) + 1
+	for _, field := range []string{b.TaxID, b.GSTIN, b.Phone} {
+		if field != "" {
+			n++
+		}
+	}
+	return n
+}
+
+func (b Branding) contactLines(l *Localizer) []string {
+	lines := append([]string{}, b.Address...)
+	if b.TaxID != "" {
+		lines = append(lines, l.T("taxId", b.TaxID))
+	}
+	if b.GSTIN != "" {
+		lines = append(lines, l.T("gstin", b.GSTIN))
+	}
+	if b.Phone != "" {
+		lines = append(lines, l.T("phone", b.Phone))
+	}
+	lines = append(lines, l.T("email", b.Email))
+	return lines
+}
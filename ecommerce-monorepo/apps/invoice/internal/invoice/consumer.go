@@ -3,13 +3,17 @@ package invoice
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/shopspring/decimal"
+	"github.com/tomarrohitt/invoice-go/internal/database"
 	"github.com/tomarrohitt/invoice-go/internal/events"
+	"github.com/tomarrohitt/invoice-go/internal/inbox"
 	"github.com/tomarrohitt/invoice-go/internal/outbox"
 )
 
@@ -21,14 +25,18 @@ type S3Uploader interface {
 type Consumer struct {
 	repo       *Repository
 	outboxRepo *outbox.Repository
+	inboxRepo  *inbox.Repository
 	s3         S3Uploader
+	generator  *PDFGenerator
 }
 
-func NewConsumer(repo *Repository, outboxRepo *outbox.Repository, s3 S3Uploader) *Consumer {
+func NewConsumer(repo *Repository, outboxRepo *outbox.Repository, inboxRepo *inbox.Repository, s3 S3Uploader, generator *PDFGenerator) *Consumer {
 	return &Consumer{
 		repo:       repo,
 		outboxRepo: outboxRepo,
+		inboxRepo:  inboxRepo,
 		s3:         s3,
+		generator:  generator,
 	}
 }
 
@@ -37,6 +45,127 @@ func (c *Consumer) HandleOrderPaid(payload []byte) error {
 	if err := json.Unmarshal(payload, &event); err != nil {
 		return err
 	}
+	event.Data.Currency = normalizeCurrency(event.Data.Currency)
+	event.Data.Locale = resolveLocale(event.Data.Locale, event.Data.BillingAddress.Country)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	messageID := "order.paid:" + event.Data.OrderID
+
+	if errs := ValidateOrderPaid(event); errs != nil {
+		if event.Data.OrderID == "" {
+			messageID = ""
+		}
+		log.Printf("[Invoice] Quarantining order.paid for Order %q: %v", event.Data.OrderID, errs)
+		return c.repo.Quarantine(ctx, messageID, "order.paid", payload, errs)
+	}
+
+	status, err := c.inboxRepo.Claim(ctx, messageID, "order.paid")
+	if err != nil {
+		return err
+	}
+	if status == inbox.StatusCompleted {
+		log.Printf("[Invoice] Skipping duplicate order.paid for Order: %s", event.Data.OrderID)
+		return nil
+	}
+
+	if err := c.generateInvoice(ctx, event, payload, messageID); err != nil {
+		if releaseErr := c.inboxRepo.Release(context.Background(), messageID); releaseErr != nil {
+			log.Printf("Failed to release inbox claim %s: %v", messageID, releaseErr)
+		}
+		return err
+	}
+
+	log.Printf("[Invoice] Successfully processed Order: %s", event.Data.OrderID)
+	return nil
+}
+
+func (c *Consumer) generateInvoice(ctx context.Context, event events.OrderPaidEvent, payload []byte, messageID string) error {
+	gst, err := c.generator.taxBreakdown(event)
+	if err != nil {
+		return fmt.Errorf("compute gst for order %s: %w", event.Data.OrderID, err)
+	}
+
+	inv, ready, err := c.repo.BeginAttempt(ctx, Invoice{
+		ID:       uuid.New().String(),
+		OrderID:  event.Data.OrderID,
+		UserID:   event.Data.UserID,
+		Amount:   currencyFor(event.Data.Currency).Round(event.Data.TotalAmount),
+		Currency: event.Data.Currency,
+		Locale:   event.Data.Locale,
+	})
+	if errors.Is(err, ErrOrderCancelled) {
+		log.Printf("[Invoice] Not invoicing cancelled Order: %s", event.Data.OrderID)
+		return c.inboxRepo.MarkCompleted(ctx, messageID)
+	}
+	if err != nil {
+		return err
+	}
+	if !ready {
+		log.Printf("[Invoice] Invoice %s already %s for Order: %s", inv.ID, inv.Status, event.Data.OrderID)
+		return c.inboxRepo.MarkCompleted(ctx, messageID)
+	}
+
+	inv.SourceEvent = payload
+	inv.Items, inv.Billing = snapshotFromEvent(event, gst)
+
+	if err := c.issueInvoice(ctx, inv, event, messageID); err != nil {
+		if markErr := c.repo.MarkFailedWithEvent(context.Background(), *inv, err, c.outboxRepo); markErr != nil {
+			log.Printf("Failed to record invoice failure for order %s: %v", event.Data.OrderID, markErr)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// issueInvoice numbers, renders and stores a PENDING invoice. Any error it
+// returns leaves the invoice to be marked FAILED by the caller.
+func (c *Consumer) issueInvoice(ctx context.Context, inv *Invoice, event events.OrderPaidEvent, messageID string) error {
+	number, issuedAt, err := c.repo.ReserveNumber(ctx, inv.ID)
+	if err != nil {
+		return fmt.Errorf("reserve invoice number: %w", err)
+	}
+	inv.InvoiceNumber = &number
+	inv.IssuedAt = &issuedAt
+
+	inv.PDFURL, err = c.renderAndUpload(ctx, event, number, issuedAt)
+	if err != nil {
+		return err
+	}
+
+	err = c.repo.CompleteWithEvent(ctx, *inv, c.outboxRepo, c.inboxRepo, messageID)
+	if err != nil {
+		log.Printf("Failed to save invoice record for order %s: %v", event.Data.OrderID, err)
+		return err
+	}
+
+	return nil
+}
+
+func (c *Consumer) renderAndUpload(ctx context.Context, event events.OrderPaidEvent, invoiceNumber string, issuedAt time.Time) (string, error) {
+	pdfBytes, err := c.generator.Generate(event, invoiceNumber, issuedAt)
+	if err != nil {
+		log.Printf("PDF Gen failed for order %s: %v", event.Data.OrderID, err)
+		return "", fmt.Errorf("generate pdf: %w", err)
+	}
+
+	s3Key := fmt.Sprintf("uploads/invoices/%s/%s.pdf", event.Data.UserID, event.Data.OrderID)
+	uploadedKey, err := c.s3.UploadInvoice(ctx, s3Key, pdfBytes)
+	if err != nil {
+		log.Printf("S3 upload failed for order %s: %v", event.Data.OrderID, err)
+		return "", err
+	}
+
+	return uploadedKey, nil
+}
+
+func (c *Consumer) HandleOrderCancelled(payload []byte) error {
+	var event events.OrderCancelledEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return err
+	}
 
 	if event.Data.OrderID == "" {
 		return nil
@@ -45,41 +174,290 @@ func (c *Consumer) HandleOrderPaid(payload []byte) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	existing, err := c.repo.GetInvoiceByOrderID(ctx, event.Data.OrderID)
-	if err == nil && existing != nil {
+	inv, err := c.repo.GetInvoiceByOrderID(ctx, event.Data.OrderID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		recorded, err := c.repo.RecordCancellation(ctx, event.Data.OrderID, event.Data.Reason)
+		if err != nil {
+			return err
+		}
+		if recorded {
+			log.Printf("[Invoice] Recorded cancellation ahead of invoice for Order: %s", event.Data.OrderID)
+			return nil
+		}
+		inv, err = c.repo.GetInvoiceByOrderID(ctx, event.Data.OrderID)
+	}
+	if err != nil {
+		return err
+	}
+	if inv.Status == StatusVoid {
 		return nil
 	}
 
-	invoiceID := uuid.New().String()
+	messageID := "order.cancelled:" + event.Data.OrderID
 
-	generator := NewPDFGenerator()
-	pdfBytes, err := generator.Generate(event, invoiceID)
+	status, err := c.inboxRepo.Claim(ctx, messageID, "order.cancelled")
 	if err != nil {
-		log.Printf("PDF Gen failed for order %s: %v", event.Data.OrderID, err)
+		return err
+	}
+	if status == inbox.StatusCompleted {
+		log.Printf("[Invoice] Skipping duplicate order.cancelled for Order: %s", event.Data.OrderID)
+		return nil
+	}
+
+	if err := c.voidInvoice(ctx, inv, event.Data.Reason, messageID); err != nil {
+		if releaseErr := c.inboxRepo.Release(context.Background(), messageID); releaseErr != nil {
+			log.Printf("Failed to release inbox claim %s: %v", messageID, releaseErr)
+		}
 		return err
 	}
 
-	s3Key := fmt.Sprintf("uploads/invoices/%s/%s.pdf", event.Data.UserID, event.Data.OrderID)
+	log.Printf("[Invoice] Voided invoice %s for cancelled Order: %s", inv.ID, event.Data.OrderID)
+	return nil
+}
+
+func (c *Consumer) voidInvoice(ctx context.Context, inv *Invoice, reason string, messageID string) error {
+	if reason == "" {
+		reason = "Order cancelled"
+	}
+
+	original, sellerGSTIN, found, err := c.loadOrderData(ctx, inv)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		log.Printf("[Invoice] No stored order data for invoice %s, keeping original PDF", inv.ID)
+	} else {
+		pdfBytes, err := c.generator.GenerateVoid(original, sellerGSTIN, inv.DisplayNumber(), inv.IssueDate(), reason)
+		if err != nil {
+			log.Printf("Void PDF Gen failed for order %s: %v", inv.OrderID, err)
+			return err
+		}
+
+		s3Key := fmt.Sprintf("uploads/invoices/%s/%s-void.pdf", inv.UserID, inv.OrderID)
+		uploadedKey, err := c.s3.UploadInvoice(ctx, s3Key, pdfBytes)
+		if err != nil {
+			log.Printf("S3 upload failed for void invoice %s: %v", inv.ID, err)
+			return err
+		}
+		inv.PDFURL = uploadedKey
+	}
+
+	err = c.repo.VoidWithEvent(ctx, *inv, reason, c.outboxRepo, c.inboxRepo, messageID)
+	if errors.Is(err, ErrInvalidTransition) {
+		log.Printf("[Invoice] Cannot void invoice %s: %v", inv.ID, err)
+		return c.inboxRepo.MarkCompleted(ctx, messageID)
+	}
+	return err
+}
+
+// loadOrderData rebuilds the order an invoice was issued for, together with
+// the seller GSTIN recorded in its snapshot. Invoices from before snapshots
+// fall back to the stored event and the configured GSTIN.
+func (c *Consumer) loadOrderData(ctx context.Context, inv *Invoice) (events.OrderPaidEvent, string, bool, error) {
+	billing, err := c.repo.GetBillingSnapshot(ctx, inv.ID)
+	if err == nil {
+		items, err := c.repo.GetItems(ctx, inv.ID)
+		if err != nil {
+			return events.OrderPaidEvent{}, "", false, err
+		}
+		return billing.OrderPaidEvent(inv, items), billing.SellerGSTIN, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return events.OrderPaidEvent{}, "", false, err
+	}
+
+	var original events.OrderPaidEvent
+	if len(inv.SourceEvent) == 0 {
+		return original, "", false, nil
+	}
+	if err := json.Unmarshal(inv.SourceEvent, &original); err != nil {
+		return original, "", false, err
+	}
+	return original, c.generator.Branding.GSTIN, true, nil
+}
+
+func (c *Consumer) HandleOrderRefunded(payload []byte) error {
+	var event events.OrderRefundedEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return err
+	}
+
+	if event.Data.OrderID == "" || event.Data.RefundID == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	messageID := "order.refunded:" + event.Data.RefundID
+
+	status, err := c.inboxRepo.Claim(ctx, messageID, "order.refunded")
+	if err != nil {
+		return err
+	}
+	if status == inbox.StatusCompleted {
+		log.Printf("[CreditNote] Skipping duplicate order.refunded for Refund: %s", event.Data.RefundID)
+		return nil
+	}
+
+	err = c.generateCreditNote(ctx, event, messageID)
+	if errors.Is(err, ErrCreditExceedsBalance) {
+		log.Printf("[CreditNote] Quarantining order.refunded for Refund %s: %v", event.Data.RefundID, err)
+		errs := ValidationErrors{{Field: "data.amount", Message: err.Error()}}
+		if err = c.repo.Quarantine(ctx, messageID, "order.refunded", payload, errs); err == nil {
+			return c.inboxRepo.MarkCompleted(ctx, messageID)
+		}
+	}
+	if err != nil {
+		if releaseErr := c.inboxRepo.Release(context.Background(), messageID); releaseErr != nil {
+			log.Printf("Failed to release inbox claim %s: %v", messageID, releaseErr)
+		}
+		return err
+	}
+
+	log.Printf("[CreditNote] Successfully processed Refund: %s for Order: %s", event.Data.RefundID, event.Data.OrderID)
+	return nil
+}
+
+func (c *Consumer) generateCreditNote(ctx context.Context, event events.OrderRefundedEvent, messageID string) error {
+	inv, err := c.repo.GetInvoiceByOrderID(ctx, event.Data.OrderID)
+	if err != nil {
+		return fmt.Errorf("invoice for order %s: %w", event.Data.OrderID, err)
+	}
+
+	switch inv.Status {
+	case StatusGenerated:
+	case StatusPending, StatusFailed:
+		return fmt.Errorf("refund %s: invoice %s is %s, retrying once it is generated", event.Data.RefundID, inv.ID, inv.Status)
+	default:
+		log.Printf("[CreditNote] Invoice %s is %s, not issuing credit note for Refund: %s", inv.ID, inv.Status, event.Data.RefundID)
+		return c.inboxRepo.MarkCompleted(ctx, messageID)
+	}
+
+	billing, err := c.repo.GetBillingSnapshot(ctx, inv.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	if billing != nil {
+		inv.Billing = *billing
+	}
+
+	credited, err := c.repo.GetCreditedAmount(ctx, inv.ID)
+	if err != nil {
+		return err
+	}
+	remaining := inv.Amount.Sub(credited)
+	currency := currencyFor(inv.Currency)
+
+	amount := event.Data.Amount
+	if amount.IsZero() {
+		if len(event.Data.Items) == 0 {
+			amount = remaining
+		} else {
+			items, err := c.repo.GetItems(ctx, inv.ID)
+			if err != nil {
+				return err
+			}
+			amount = itemCreditAmount(event.Data.Items, items, inv.Billing, currency)
+
+			// Each line's tax share is rounded on its own, so crediting every
+			// remaining item can land a few minor units past the balance.
+			slack := decimal.New(int64(len(event.Data.Items)), -currency.MinorUnits)
+			if amount.GreaterThan(remaining) && amount.Sub(remaining).LessThanOrEqual(slack) {
+				amount = remaining
+			}
+		}
+	}
+	amount = currency.Round(amount)
+
+	if !amount.IsPositive() {
+		return fmt.Errorf("refund %s amount %s is not positive", event.Data.RefundID, currency.String(amount))
+	}
+	if amount.GreaterThan(remaining) {
+		return fmt.Errorf("%w: refund %s amount %s, remaining %s", ErrCreditExceedsBalance, event.Data.RefundID, currency.String(amount), currency.String(remaining))
+	}
+
+	number, issuedAt, err := c.repo.ReserveCreditNoteNumber(ctx, event.Data.RefundID)
+	if err != nil {
+		return fmt.Errorf("reserve credit note number for refund %s: %w", event.Data.RefundID, err)
+	}
+
+	pdfBytes, err := c.generator.GenerateCreditNote(event, number, issuedAt, inv, amount)
+	if err != nil {
+		log.Printf("Credit note PDF Gen failed for refund %s: %v", event.Data.RefundID, err)
+		return err
+	}
+
+	s3Key := fmt.Sprintf("uploads/credit-notes/%s/%s.pdf", inv.UserID, event.Data.RefundID)
 	uploadedKey, err := c.s3.UploadInvoice(ctx, s3Key, pdfBytes)
 	if err != nil {
-		log.Printf("S3 upload failed for order %s: %v", event.Data.OrderID, err)
+		log.Printf("S3 upload failed for refund %s: %v", event.Data.RefundID, err)
 		return err
 	}
 
-	inv := Invoice{
-		ID:      invoiceID,
-		OrderID: event.Data.OrderID,
-		UserID:  event.Data.UserID,
-		Amount:  decimal.NewFromFloat(event.Data.TotalAmount),
-		Status:  "COMPLETED",
-		PDFURL:  uploadedKey,
+	cn := CreditNote{
+		ID:        uuid.New().String(),
+		Number:    number,
+		IssuedAt:  issuedAt,
+		InvoiceID: inv.ID,
+		OrderID:   inv.OrderID,
+		RefundID:  event.Data.RefundID,
+		UserID:    inv.UserID,
+		Amount:    amount,
+		Currency:  inv.Currency,
+		Reason:    event.Data.Reason,
+		Items:     event.Data.Items,
+		PDFURL:    uploadedKey,
 	}
 
-	if err := c.repo.CreateWithEvent(ctx, inv, c.outboxRepo); err != nil {
-		log.Printf("Failed to save invoice record for order %s: %v", event.Data.OrderID, err)
+	err = c.repo.CreateCreditNoteWithEvent(ctx, cn, c.outboxRepo, c.inboxRepo, messageID)
+	if database.IsUniqueViolation(err) {
+		log.Printf("[CreditNote] Credit note already exists for Refund: %s", event.Data.RefundID)
+		return c.inboxRepo.MarkCompleted(ctx, messageID)
+	}
+	if errors.Is(err, ErrNotCreditable) {
+		log.Printf("[CreditNote] Not issuing credit note for Refund: %s: %v", event.Data.RefundID, err)
+		return c.inboxRepo.MarkCompleted(ctx, messageID)
+	}
+	if err != nil {
+		log.Printf("Failed to save credit note for refund %s: %v", event.Data.RefundID, err)
 		return err
 	}
 
-	log.Printf("[Invoice] Successfully processed Order: %s", event.Data.OrderID)
 	return nil
 }
+
+// itemCreditAmount credits the refunded items at their invoiced price plus
+// their share of the invoice tax: the stored GST of the matching line, or the
+// single tax amount apportioned by line value when the invoice had no GST.
+func itemCreditAmount(refunded []events.OrderItem, invoiced []Item, billing BillingSnapshot, currency Currency) decimal.Decimal {
+	byProduct := make(map[string]Item, len(invoiced))
+	for _, item := range invoiced {
+		byProduct[itemKey(item.ProductID, item.Name)] = item
+	}
+
+	var amount decimal.Decimal
+	for _, item := range refunded {
+		line := currency.LineTotal(item.Price, item.Quantity)
+		amount = amount.Add(line)
+
+		stored, ok := byProduct[itemKey(item.ProductID, item.Name)]
+		lineTax := stored.CGST.Add(stored.SGST).Add(stored.IGST)
+		switch {
+		case ok && lineTax.IsPositive() && stored.Quantity > 0:
+			share := lineTax.Mul(decimal.NewFromInt(int64(item.Quantity))).Div(decimal.NewFromInt(int64(stored.Quantity)))
+			amount = amount.Add(currency.Round(share))
+		case billing.Subtotal.IsPositive():
+			amount = amount.Add(currency.Round(billing.TaxAmount.Mul(line).Div(billing.Subtotal)))
+		}
+	}
+	return amount
+}
+
+func itemKey(productID, name string) string {
+	if productID != "" {
+		return productID
+	}
+	return "name:" + name
+}
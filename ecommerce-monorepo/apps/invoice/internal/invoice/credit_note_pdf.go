@@ -0,0 +1,72 @@
+package invoice
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tomarrohitt/invoice-go/internal/events"
+)
+
+func (g *PDFGenerator) GenerateCreditNote(event events.OrderRefundedEvent, number string, issuedAt time.Time, inv *Invoice, amount decimal.Decimal) ([]byte, error) {
+	d := g.newDocument(resolveLocale(inv.Locale, inv.Billing.BillingAddress.Country))
+
+	g.generateHeader(d, "creditNoteNo")
+	g.generateCreditNoteInfo(d, event, number, issuedAt, inv)
+
+	items := event.Data.Items
+	if len(items) == 0 {
+		items = []events.OrderItem{{
+			Name:     d.T("fullRefund", inv.DisplayNumber()),
+			Price:    amount,
+			Quantity: 1,
+		}}
+	}
+	currency := currencyFor(inv.Currency)
+	g.generateTable(d, items, currency)
+	g.generateCreditNoteTotals(d, amount, currency)
+
+	return output(d.Fpdf)
+}
+
+func (g *PDFGenerator) generateCreditNoteInfo(d *document, event events.OrderRefundedEvent, number string, issuedAt time.Time, inv *Invoice) {
+	d.SetFont(fontFamily, "", 9)
+	d.SetTextColor(g.TextColor[0], g.TextColor[1], g.TextColor[2])
+	d.cellAt(140, 25, 55, 5, number, "L")
+	d.cellAt(140, 30, 55, 5, d.FormatDate(issuedAt), "L")
+
+	d.SetFillColor(g.GrayColor[0], g.GrayColor[1], g.GrayColor[2])
+	d.rect(15, 55, 180, 50, "F")
+
+	d.SetFont(fontFamily, "B", 10)
+	d.SetTextColor(g.PrimaryColor[0], g.PrimaryColor[1], g.PrimaryColor[2])
+	d.cellAt(20, 60, 170, 5, d.T("creditNote"), "L")
+
+	d.SetFont(fontFamily, "", 9)
+	d.SetTextColor(g.TextColor[0], g.TextColor[1], g.TextColor[2])
+	d.cellAt(20, 68, 170, 5, d.T("originalInvoice", inv.DisplayNumber()), "L")
+	d.cellAt(20, 74, 170, 5, d.T("invoiceDate", d.FormatDate(inv.IssueDate())), "L")
+	d.cellAt(20, 80, 170, 5, d.T("orderId", event.Data.OrderID), "L")
+	d.cellAt(20, 86, 170, 5, d.T("refundId", event.Data.RefundID), "L")
+	if event.Data.Reason != "" {
+		d.cellAt(20, 92, 170, 5, d.T("reason", event.Data.Reason), "L")
+	}
+}
+
+func (g *PDFGenerator) generateCreditNoteTotals(d *document, amount decimal.Decimal, currency Currency) {
+	ensureSpace(d.Fpdf, 2+totalsRowHeight)
+	d.Ln(2)
+
+	var startX float64 = 125
+	var labelWidth float64 = 35
+	var valueWidth float64 = 35
+
+	d.SetDrawColor(g.GrayColor[0], g.GrayColor[1], g.GrayColor[2])
+	d.line(startX, d.GetY(), startX+labelWidth+valueWidth)
+
+	d.SetFont(fontFamily, "B", 10)
+	d.SetTextColor(g.PrimaryColor[0], g.PrimaryColor[1], g.PrimaryColor[2])
+	d.row(startX, totalsRowHeight, []column{
+		{labelWidth, d.T("totalCredited"), "L"},
+		{valueWidth, currency.Format(amount), "R"},
+	}, false)
+}
@@ -0,0 +1,222 @@
+package invoice
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+type document struct {
+	*gofpdf.Fpdf
+	*Localizer
+
+	fonts     *FontSet
+	families  []string
+	loaded    map[string]bool
+	fontStyle string
+	fontSize  float64
+}
+
+type column struct {
+	width float64
+	text  string
+	align string
+}
+
+func (d *document) mirrorX(x, w float64) float64 {
+	if !d.rtl {
+		return x
+	}
+	pageWidth, _ := d.GetPageSize()
+	return pageWidth - x - w
+}
+
+func (d *document) align(a string) string {
+	if !d.rtl {
+		return a
+	}
+	switch a {
+	case "L":
+		return "R"
+	case "R":
+		return "L"
+	}
+	return a
+}
+
+func (d *document) text(s string) string {
+	return visualOrder(shapeArabic(s), d.rtl)
+}
+
+// SetFont records the style and size so text in another script can switch
+// to a covering family at the same settings.
+func (d *document) SetFont(family, style string, size float64) {
+	d.fontStyle, d.fontSize = style, size
+	d.Fpdf.SetFont(family, style, size)
+}
+
+// useFontFor switches to the first fallback family with glyphs for s and
+// returns a func that switches back.
+func (d *document) useFontFor(s string) func() {
+	family := d.fonts.familyFor(s, d.families)
+	if family == d.families[0] {
+		return func() {}
+	}
+
+	name := fontFamily + family
+	if key := name + d.fontStyle; !d.loaded[key] {
+		d.AddUTF8FontFromBytes(name, d.fontStyle, d.fonts.families[family].style(d.fontStyle))
+		d.loaded[key] = true
+	}
+	d.Fpdf.SetFont(name, d.fontStyle, d.fontSize)
+	return func() {
+		d.Fpdf.SetFont(fontFamily, d.fontStyle, d.fontSize)
+	}
+}
+
+func (d *document) cell(w, h float64, txt string, ln int, align string, fill bool) {
+	txt = d.text(txt)
+	defer d.useFontFor(txt)()
+	d.CellFormat(w, h, txt, "", ln, d.align(align), fill, 0, "")
+}
+
+func (d *document) cellAt(x, y, w, h float64, txt, align string) {
+	d.SetXY(d.mirrorX(x, w), y)
+	d.cell(w, h, txt, 0, align, false)
+}
+
+func (d *document) textAt(x, y float64, s string) {
+	s = d.text(s)
+	defer d.useFontFor(s)()
+	if d.rtl {
+		pageWidth, _ := d.GetPageSize()
+		x = pageWidth - x - d.GetStringWidth(s)
+	}
+	d.Text(x, y, s)
+}
+
+func (d *document) rect(x, y, w, h float64, style string) {
+	d.Rect(d.mirrorX(x, w), y, w, h, style)
+}
+
+func (d *document) line(x1, y, x2 float64) {
+	d.Line(d.mirrorX(x1, 0), y, d.mirrorX(x2, 0), y)
+}
+
+func (d *document) row(x, h float64, cols []column, fill bool) {
+	var total float64
+	for _, c := range cols {
+		total += c.width
+	}
+	d.SetX(d.mirrorX(x, total))
+
+	for i := range cols {
+		c := cols[i]
+		if d.rtl {
+			c = cols[len(cols)-1-i]
+		}
+		ln := 0
+		if i == len(cols)-1 {
+			ln = 1
+		}
+		d.cell(c.width, h, c.text, ln, c.align, fill)
+	}
+}
+
+func isRTLRune(r rune) bool {
+	return unicode.In(r, unicode.Arabic, unicode.Hebrew)
+}
+
+func isLTRRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Sc, r) || r == '{' || r == '}'
+}
+
+func visualOrder(s string, rtlBase bool) string {
+	if strings.IndexFunc(s, isRTLRune) < 0 {
+		return s
+	}
+
+	const (
+		ltr = iota
+		rtl
+		neutral
+	)
+
+	runes := []rune(s)
+	dirs := make([]int, len(runes))
+	for i, r := range runes {
+		switch {
+		case isRTLRune(r):
+			dirs[i] = rtl
+		case isLTRRune(r):
+			dirs[i] = ltr
+		case (r == '+' || r == '-') && i+1 < len(runes) && unicode.IsDigit(runes[i+1]):
+			dirs[i] = ltr
+		default:
+			dirs[i] = neutral
+		}
+	}
+
+	base := ltr
+	if rtlBase {
+		base = rtl
+	}
+	for i := 0; i < len(dirs); {
+		if dirs[i] != neutral {
+			i++
+			continue
+		}
+		j := i
+		for j < len(dirs) && dirs[j] == neutral {
+			j++
+		}
+		before, after := base, base
+		if i > 0 {
+			before = dirs[i-1]
+		}
+		if j < len(dirs) {
+			after = dirs[j]
+		}
+		resolved := base
+		if before == after {
+			resolved = before
+		}
+		for k := i; k < j; k++ {
+			dirs[k] = resolved
+		}
+		i = j
+	}
+
+	type run struct {
+		rtl   bool
+		runes []rune
+	}
+
+	var runs []run
+	for i, r := range runes {
+		isRTL := dirs[i] == rtl
+		if len(runs) == 0 || runs[len(runs)-1].rtl != isRTL {
+			runs = append(runs, run{rtl: isRTL})
+		}
+		runs[len(runs)-1].runes = append(runs[len(runs)-1].runes, r)
+	}
+
+	if rtlBase {
+		for i, j := 0, len(runs)-1; i < j; i, j = i+1, j-1 {
+			runs[i], runs[j] = runs[j], runs[i]
+		}
+	}
+
+	var b strings.Builder
+	for _, r := range runs {
+		if !r.rtl {
+			b.WriteString(string(r.runes))
+			continue
+		}
+		for i := len(r.runes) - 1; i >= 0; i-- {
+			b.WriteRune(r.runes[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,360 @@
+package invoice
+
+import (
+	"embed"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+//go:embed fonts/*.ttf
+var bundledFonts embed.FS
+
+const (
+	fontFamily        = "Invoice"
+	DefaultFontFamily = "DejaVuSansCondensed"
+)
+
+type FontConfig struct {
+	Dir           string
+	DefaultFamily string
+	Locales       map[string]string
+}
+
+type fontFace struct {
+	regular  []byte
+	bold     []byte
+	italic   []byte
+	coverage []runeRange
+}
+
+type runeRange struct {
+	lo, hi rune
+}
+
+type FontSet struct {
+	families      map[string]*fontFace
+	defaultFamily string
+	locales       map[string]string
+}
+
+func LoadFonts(cfg FontConfig) (*FontSet, error) {
+	set := &FontSet{
+		families:      make(map[string]*fontFace),
+		defaultFamily: cfg.DefaultFamily,
+		locales:       make(map[string]string),
+	}
+	if set.defaultFamily == "" {
+		set.defaultFamily = DefaultFontFamily
+	}
+
+	if err := set.loadDir(bundledFonts, "fonts"); err != nil {
+		return nil, fmt.Errorf("load bundled fonts: %w", err)
+	}
+	if cfg.Dir != "" {
+		if err := set.loadDir(os.DirFS(cfg.Dir), "."); err != nil {
+			return nil, fmt.Errorf("load fonts from %s: %w", cfg.Dir, err)
+		}
+	}
+
+	for family, face := range set.families {
+		if face.regular == nil {
+			continue
+		}
+		coverage, err := parseCoverage(face.regular)
+		if err != nil {
+			return nil, fmt.Errorf("font family %s: %w", family, err)
+		}
+		face.coverage = coverage
+	}
+
+	if err := set.checkFamily(set.defaultFamily); err != nil {
+		return nil, err
+	}
+	for locale, family := range cfg.Locales {
+		if err := set.checkFamily(family); err != nil {
+			return nil, fmt.Errorf("locale %s: %w", locale, err)
+		}
+		set.locales[strings.ToLower(locale)] = family
+	}
+
+	return set, nil
+}
+
+func (s *FontSet) loadDir(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(path.Ext(entry.Name()), ".ttf") {
+			continue
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+
+		family, style := parseFontFileName(entry.Name())
+		face, ok := s.families[family]
+		if !ok {
+			face = &fontFace{}
+			s.families[family] = face
+		}
+
+		switch style {
+		case "Bold":
+			face.bold = data
+		case "Italic", "Oblique":
+			face.italic = data
+		default:
+			face.regular = data
+		}
+	}
+	return nil
+}
+
+func parseFontFileName(name string) (family, style string) {
+	base := strings.TrimSuffix(name, path.Ext(name))
+	if i := strings.LastIndex(base, "-"); i > 0 {
+		switch style := base[i+1:]; style {
+		case "Regular", "Bold", "Italic", "Oblique":
+			return base[:i], style
+		}
+	}
+	return base, ""
+}
+
+func (s *FontSet) checkFamily(family string) error {
+	face, ok := s.families[family]
+	if !ok || face.regular == nil {
+		return fmt.Errorf("font family %s has no regular face", family)
+	}
+	return nil
+}
+
+func (s *FontSet) family(locale string) string {
+	locale = strings.ToLower(locale)
+	if family, ok := s.locales[locale]; ok {
+		return family
+	}
+	if lang, _, found := strings.Cut(locale, "-"); found {
+		if family, ok := s.locales[lang]; ok {
+			return family
+		}
+	}
+	return s.defaultFamily
+}
+
+// fallbacks orders the families tried for text the locale's family cannot
+// draw: the locale's family, the default family, then the rest by name.
+func (s *FontSet) fallbacks(locale string) []string {
+	primary := s.family(locale)
+	order := []string{primary}
+	if s.defaultFamily != primary {
+		order = append(order, s.defaultFamily)
+	}
+
+	var rest []string
+	for family, face := range s.families {
+		if face.regular != nil && family != primary && family != s.defaultFamily {
+			rest = append(rest, family)
+		}
+	}
+	sort.Strings(rest)
+	return append(order, rest...)
+}
+
+// familyFor picks the first family in order that has a glyph for every rune
+// of text, or the one missing the fewest when none covers it all.
+func (s *FontSet) familyFor(text string, order []string) string {
+	best, bestMissing := order[0], -1
+	for _, family := range order {
+		missing := s.families[family].missing(text)
+		if missing == 0 {
+			return family
+		}
+		if bestMissing < 0 || missing < bestMissing {
+			best, bestMissing = family, missing
+		}
+	}
+	return best
+}
+
+// firstUncovered returns the first rune of text that no loaded family has a
+// glyph for.
+func (s *FontSet) firstUncovered(text string) (rune, bool) {
+	for _, r := range text {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			continue
+		}
+		covered := false
+		for _, face := range s.families {
+			if face.covers(r) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return r, true
+		}
+	}
+	return 0, false
+}
+
+func (f *fontFace) missing(text string) int {
+	var n int
+	for _, r := range text {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			continue
+		}
+		if !f.covers(r) {
+			n++
+		}
+	}
+	return n
+}
+
+func (f *fontFace) covers(r rune) bool {
+	i := sort.Search(len(f.coverage), func(i int) bool { return f.coverage[i].hi >= r })
+	return i < len(f.coverage) && f.coverage[i].lo <= r
+}
+
+func (f *fontFace) style(style string) []byte {
+	switch {
+	case style == "B" && f.bold != nil:
+		return f.bold
+	case style == "I" && f.italic != nil:
+		return f.italic
+	}
+	return f.regular
+}
+
+func (s *FontSet) register(pdf *gofpdf.Fpdf, locale string) []string {
+	order := s.fallbacks(locale)
+	face := s.families[order[0]]
+
+	pdf.AddUTF8FontFromBytes(fontFamily, "", face.regular)
+	pdf.AddUTF8FontFromBytes(fontFamily, "B", face.style("B"))
+	pdf.AddUTF8FontFromBytes(fontFamily, "I", face.style("I"))
+	return order
+}
+
+// needsShaping reports whether r is in an Indic script block. gofpdf draws one
+// glyph per rune with no OpenType shaping, so these scripts lose their vowel
+// sign reordering and conjunct forms.
+func needsShaping(r rune) bool {
+	return r >= 0x0900 && r <= 0x0DFF
+}
+
+var errNoUnicodeCmap = errors.New("font has no format 4 Unicode cmap")
+
+// parseCoverage reads the runes a TrueType font maps to a glyph from the same
+// format 4 cmap subtable gofpdf renders with.
+func parseCoverage(data []byte) ([]runeRange, error) {
+	var ranges []runeRange
+	err := parseCmap(data, func(r rune, glyph int) {
+		if n := len(ranges); n > 0 && ranges[n-1].hi == r-1 {
+			ranges[n-1].hi = r
+		} else {
+			ranges = append(ranges, runeRange{r, r})
+		}
+	})
+	return ranges, err
+}
+
+// parseCmap calls visit, in rune order, for every rune the format 4 Unicode
+// cmap subtable maps to a glyph other than .notdef.
+func parseCmap(data []byte, visit func(r rune, glyph int)) error {
+	table, err := sfntTable(data, "cmap")
+	if err != nil {
+		return err
+	}
+	if len(table) < 4 {
+		return errNoUnicodeCmap
+	}
+
+	var sub []byte
+	for i, n := 0, int(binary.BigEndian.Uint16(table[2:])); i < n; i++ {
+		rec := table[4+i*8:]
+		if len(rec) < 8 {
+			return errNoUnicodeCmap
+		}
+		platform, encoding := binary.BigEndian.Uint16(rec), binary.BigEndian.Uint16(rec[2:])
+		offset := int(binary.BigEndian.Uint32(rec[4:]))
+		if (platform == 3 && encoding == 1) || platform == 0 {
+			if offset+2 <= len(table) && binary.BigEndian.Uint16(table[offset:]) == 4 {
+				sub = table[offset:]
+				break
+			}
+		}
+	}
+	if len(sub) < 14 {
+		return errNoUnicodeCmap
+	}
+
+	segments := int(binary.BigEndian.Uint16(sub[6:])) / 2
+	if len(sub) < 16+segments*8 {
+		return errNoUnicodeCmap
+	}
+	ends := sub[14:]
+	starts := sub[16+segments*2:]
+	deltas := sub[16+segments*4:]
+	offsets := sub[16+segments*6:]
+
+	for i := 0; i < segments; i++ {
+		start := int(binary.BigEndian.Uint16(starts[i*2:]))
+		end := int(binary.BigEndian.Uint16(ends[i*2:]))
+		delta := int(binary.BigEndian.Uint16(deltas[i*2:]))
+		rangeOffset := int(binary.BigEndian.Uint16(offsets[i*2:]))
+
+		for c := start; c <= end && c != 0xFFFF; c++ {
+			glyph := (c + delta) & 0xFFFF
+			if rangeOffset != 0 {
+				at := 16 + segments*6 + i*2 + rangeOffset + (c-start)*2
+				if at+2 > len(sub) {
+					break
+				}
+				glyph = int(binary.BigEndian.Uint16(sub[at:]))
+				if glyph != 0 {
+					glyph = (glyph + delta) & 0xFFFF
+				}
+			}
+			if glyph != 0 {
+				visit(rune(c), glyph)
+			}
+		}
+	}
+	return nil
+}
+
+func sfntTable(data []byte, tag string) ([]byte, error) {
+	if len(data) < 12 {
+		return nil, errors.New("not a TrueType font")
+	}
+	for i, n := 0, int(binary.BigEndian.Uint16(data[4:])); i < n; i++ {
+		rec := data[12+i*16:]
+		if len(rec) < 16 {
+			break
+		}
+		if string(rec[:4]) != tag {
+			continue
+		}
+		offset, length := int(binary.BigEndian.Uint32(rec[8:])), int(binary.BigEndian.Uint32(rec[12:]))
+		if offset+length > len(data) {
+			return nil, fmt.Errorf("%s table is truncated", tag)
+		}
+		return data[offset : offset+length], nil
+	}
+	return nil, fmt.Errorf("font has no %s table", tag)
+}
@@ -0,0 +1,390 @@
+package invoice
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	latinExtendedName = "Zoë Łukasiewicz-Ødegård"
+	devanagariName    = "राहुल शर्मा"
+	cjkName           = "李雷"
+)
+
+func TestFontFallbackByGlyphCoverage(t *testing.T) {
+	dir := t.TempDir()
+	writeCoverageFont(t, filepath.Join(dir, "CoverageTest.ttf"), devanagariName+cjkName)
+
+	fonts, err := LoadFonts(FontConfig{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		family string
+	}{
+		{latinExtendedName, DefaultFontFamily},
+		{devanagariName, "CoverageTest"},
+		{cjkName, "CoverageTest"},
+	}
+
+	for _, locale := range []string{"en", "en-IN"} {
+		for _, tt := range tests {
+			if got := fonts.familyFor(tt.name, fonts.fallbacks(locale)); got != tt.family {
+				t.Errorf("%s: %q drawn with %s, want %s", locale, tt.name, got, tt.family)
+			}
+		}
+	}
+}
+
+func TestRenderNamesInOtherScripts(t *testing.T) {
+	dir := t.TempDir()
+	writeCoverageFont(t, filepath.Join(dir, "CoverageTest.ttf"), devanagariName+cjkName)
+
+	fonts, err := LoadFonts(FontConfig{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := testGenerator(t)
+	g.Fonts = fonts
+
+	tests := []struct {
+		name string
+		font string
+	}{
+		{latinExtendedName, "utf8invoice"},
+		{devanagariName, "utf8invoicecoveragetest"},
+		{cjkName, "utf8invoicecoveragetest"},
+	}
+
+	for _, locale := range []string{"en", "en-IN"} {
+		for _, tt := range tests {
+			event := orderWithItems(1)
+			event.Data.Locale = locale
+			event.Data.UserName = tt.name
+
+			d, err := g.render(event, g.Branding.GSTIN, "INV/2026/000001", time.Date(2026, time.March, 5, 10, 0, 0, 0, time.UTC))
+			if err != nil {
+				t.Fatal(err)
+			}
+			d.SetCompression(false)
+			pdf, err := output(d.Fpdf)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			runs := fontRuns(t, pdf)
+			font, ok := runs[tt.name]
+			if !ok {
+				t.Fatalf("%s: %q was not drawn", locale, tt.name)
+			}
+			if font != tt.font {
+				t.Errorf("%s: %q drawn with %s, want %s", locale, tt.name, font, tt.font)
+			}
+		}
+	}
+}
+
+// TestEmbeddedFontUsesRealGlyphs follows each rune of a rendered name through
+// the PDF's CIDToGIDMap into the embedded font subset and checks it lands on
+// the same outline the bundled font's cmap gives that rune.
+func TestEmbeddedFontUsesRealGlyphs(t *testing.T) {
+	event := orderWithItems(1)
+	event.Data.UserName = latinExtendedName
+	d, err := testGenerator(t).render(event, "", "INV/2026/000001", time.Date(2026, time.March, 5, 10, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.SetCompression(false)
+	pdf, err := output(d.Fpdf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	drawnWith, ok := styledFontRuns(t, pdf)[latinExtendedName]
+	if !ok {
+		t.Fatalf("%q was not drawn", latinExtendedName)
+	}
+	cidToGID, subset := embeddedFont(t, pdf, drawnWith)
+
+	file := map[string]string{
+		"utf8invoice":  "fonts/DejaVuSansCondensed.ttf",
+		"utf8invoiceB": "fonts/DejaVuSansCondensed-Bold.ttf",
+		"utf8invoiceI": "fonts/DejaVuSansCondensed-Oblique.ttf",
+	}[drawnWith]
+	source, err := bundledFonts.ReadFile(file)
+	if err != nil {
+		t.Fatalf("%s: %v", drawnWith, err)
+	}
+	sourceGlyphs := make(map[rune]int)
+	if err := parseCmap(source, func(r rune, glyph int) { sourceGlyphs[r] = glyph }); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range latinExtendedName {
+		want, ok := sourceGlyphs[r]
+		if !ok {
+			t.Fatalf("bundled font has no glyph for %q", r)
+		}
+		if int(r)*2+2 > len(cidToGID) {
+			t.Fatalf("%q is outside the CIDToGIDMap", r)
+		}
+		gid := int(binary.BigEndian.Uint16(cidToGID[r*2:]))
+		if gid == 0 {
+			t.Errorf("%q maps to .notdef in the embedded font", r)
+			continue
+		}
+		if got, want := glyphHeader(t, subset, gid), glyphHeader(t, source, want); !bytes.Equal(got, want) {
+			t.Errorf("%q: embedded glyph %d has bounds %x, want %x", r, gid, got, want)
+		}
+	}
+}
+
+var (
+	cidFontPattern        = regexp.MustCompile(`(?s)/Subtype /CIDFontType2\n/BaseFont /(\w+)\n.*?/CIDToGIDMap (\d+) 0 R`)
+	fontDescriptorPattern = regexp.MustCompile(`/FontName /(\w+)\n /Ascent[^\n]*?/FontFile2 (\d+) 0 R`)
+	streamLengthPattern   = regexp.MustCompile(`/Length (\d+)`)
+)
+
+// embeddedFont returns the CIDToGIDMap and the font program gofpdf embedded
+// for baseFont.
+func embeddedFont(t *testing.T, pdf []byte, baseFont string) (cidToGID, font []byte) {
+	t.Helper()
+
+	for _, m := range cidFontPattern.FindAllSubmatch(pdf, -1) {
+		if string(m[1]) == baseFont {
+			cidToGID = pdfStream(t, pdf, string(m[2]))
+		}
+	}
+	for _, m := range fontDescriptorPattern.FindAllSubmatch(pdf, -1) {
+		if string(m[1]) == baseFont {
+			font = pdfStream(t, pdf, string(m[2]))
+		}
+	}
+	if cidToGID == nil || font == nil {
+		t.Fatalf("font %s is not embedded", baseFont)
+	}
+	return cidToGID, font
+}
+
+// pdfStream returns the decoded stream of object id.
+func pdfStream(t *testing.T, pdf []byte, id string) []byte {
+	t.Helper()
+
+	start := bytes.Index(pdf, []byte("\n"+id+" 0 obj\n"))
+	if start < 0 {
+		t.Fatalf("object %s not found", id)
+	}
+	body := pdf[start:]
+	dictEnd := bytes.Index(body, []byte("stream\n"))
+	if dictEnd < 0 {
+		t.Fatalf("object %s has no stream", id)
+	}
+	dict := body[:dictEnd]
+	m := streamLengthPattern.FindSubmatch(dict)
+	if m == nil {
+		t.Fatalf("object %s has no stream length", id)
+	}
+	length, _ := strconv.Atoi(string(m[1]))
+	data := body[dictEnd+len("stream\n"):][:length]
+
+	if !bytes.Contains(dict, []byte("/FlateDecode")) {
+		return data
+	}
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("object %s: %v", id, err)
+	}
+	decoded, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("object %s: %v", id, err)
+	}
+	return decoded
+}
+
+// glyphHeader returns the contour count and bounding box of glyph gid, which
+// survive subsetting unchanged even for composite glyphs.
+func glyphHeader(t *testing.T, font []byte, gid int) []byte {
+	t.Helper()
+
+	head, err := sfntTable(font, "head")
+	if err != nil {
+		t.Fatal(err)
+	}
+	loca, err := sfntTable(font, "loca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	glyf, err := sfntTable(font, "glyf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var offset, next int
+	if binary.BigEndian.Uint16(head[50:]) == 0 {
+		offset = int(binary.BigEndian.Uint16(loca[gid*2:])) * 2
+		next = int(binary.BigEndian.Uint16(loca[gid*2+2:])) * 2
+	} else {
+		offset = int(binary.BigEndian.Uint32(loca[gid*4:]))
+		next = int(binary.BigEndian.Uint32(loca[gid*4+4:]))
+	}
+	if next-offset < 10 {
+		return nil
+	}
+	return glyf[offset : offset+10]
+}
+
+var (
+	fontSelectPattern   = regexp.MustCompile(`BT /(F\w+) [\d.]+ Tf ET|BT [\d.-]+ [\d.-]+ Td \(((?:\\.|[^\\)])*)\) ?Tj ET`)
+	fontResourcePattern = regexp.MustCompile(`/(F\w+) (\d+) 0 R`)
+	fontObjectPattern   = regexp.MustCompile(`(\d+) 0 obj\n<</Type /Font\n/Subtype /Type0\n/BaseFont /(\w+)`)
+)
+
+// fontRuns maps each text run of an uncompressed PDF to the base font it was
+// drawn with, minus gofpdf's upper-case style suffix.
+func fontRuns(t *testing.T, pdf []byte) map[string]string {
+	t.Helper()
+
+	runs := styledFontRuns(t, pdf)
+	for text, font := range runs {
+		runs[text] = strings.TrimRight(font, "BI")
+	}
+	return runs
+}
+
+// styledFontRuns maps each text run of an uncompressed PDF to the exact base
+// font it was drawn with.
+func styledFontRuns(t *testing.T, pdf []byte) map[string]string {
+	t.Helper()
+
+	baseFonts := make(map[string]string)
+	for _, m := range fontObjectPattern.FindAllSubmatch(pdf, -1) {
+		baseFonts[string(m[1])] = string(m[2])
+	}
+	resources := make(map[string]string)
+	for _, m := range fontResourcePattern.FindAllSubmatch(pdf, -1) {
+		if name, ok := baseFonts[string(m[2])]; ok {
+			resources[string(m[1])] = name
+		}
+	}
+
+	runs := make(map[string]string)
+	var current string
+	for _, m := range fontSelectPattern.FindAllSubmatch(pdf, -1) {
+		if m[1] != nil {
+			current = resources[string(m[1])]
+			continue
+		}
+		runs[decodeTextRun(m[2])] = current
+	}
+	return runs
+}
+
+// writeCoverageFont copies the bundled DejaVu font with its cmap replaced by
+// one that maps only the runes of text, each to the glyph for "A".
+func writeCoverageFont(t *testing.T, path, text string) {
+	t.Helper()
+
+	const glyphA = 36
+
+	base, err := bundledFonts.ReadFile("fonts/DejaVuSansCondensed.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[rune]bool)
+	var runes []rune
+	for _, r := range text {
+		if r != ' ' && !seen[r] {
+			seen[r] = true
+			runes = append(runes, r)
+		}
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	runes = append(runes, 0xFFFF)
+
+	segments := len(runes)
+	searchRange := 2
+	for searchRange*2 <= segments*2 {
+		searchRange *= 2
+	}
+	var entrySelector uint16
+	for n := searchRange / 2; n > 1; n /= 2 {
+		entrySelector++
+	}
+
+	var sub bytes.Buffer
+	put := func(v uint16) { binary.Write(&sub, binary.BigEndian, v) }
+	put(4)
+	put(uint16(16 + segments*8))
+	put(0)
+	put(uint16(segments * 2))
+	put(uint16(searchRange))
+	put(entrySelector)
+	put(uint16(segments*2 - searchRange))
+	for _, r := range runes {
+		put(uint16(r))
+	}
+	put(0)
+	for _, r := range runes {
+		put(uint16(r))
+	}
+	for _, r := range runes {
+		if r == 0xFFFF {
+			put(1)
+			continue
+		}
+		put(uint16(glyphA - r))
+	}
+	for range runes {
+		put(0)
+	}
+
+	var cmap bytes.Buffer
+	binary.Write(&cmap, binary.BigEndian, []uint16{0, 1, 3, 1})
+	binary.Write(&cmap, binary.BigEndian, uint32(12))
+	cmap.Write(sub.Bytes())
+
+	numTables := int(binary.BigEndian.Uint16(base[4:]))
+	type table struct {
+		tag  string
+		data []byte
+	}
+	tables := make([]table, numTables)
+	for i := range tables {
+		rec := base[12+i*16:]
+		offset, length := binary.BigEndian.Uint32(rec[8:]), binary.BigEndian.Uint32(rec[12:])
+		tables[i] = table{string(rec[:4]), base[offset : offset+length]}
+		if tables[i].tag == "cmap" {
+			tables[i].data = cmap.Bytes()
+		}
+	}
+
+	var out bytes.Buffer
+	out.Write(base[:12])
+	offset := 12 + numTables*16
+	for _, tb := range tables {
+		out.WriteString(tb.tag)
+		binary.Write(&out, binary.BigEndian, []uint32{0, uint32(offset), uint32(len(tb.data))})
+		offset += (len(tb.data) + 3) &^ 3
+	}
+	for _, tb := range tables {
+		out.Write(tb.data)
+		out.Write(make([]byte, (4-len(tb.data)%4)%4))
+	}
+
+	if err := os.WriteFile(path, out.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
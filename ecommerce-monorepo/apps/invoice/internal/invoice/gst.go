@@ -0,0 +1,223 @@
+package invoice
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/tomarrohitt/invoice-go/internal/events"
+)
+
+var (
+	gstinPattern   = regexp.MustCompile(`^[0-9]{2}[A-Z]{5}[0-9]{4}[A-Z][1-9A-Z]Z[0-9A-Z]$`)
+	hsnCodePattern = regexp.MustCompile(`^[0-9]{4}([0-9]{2}){0,2}$`)
+	hundred        = decimal.NewFromInt(100)
+	two            = decimal.NewFromInt(2)
+)
+
+var gstStates = map[string]string{
+	"01": "Jammu and Kashmir",
+	"02": "Himachal Pradesh",
+	"03": "Punjab",
+	"04": "Chandigarh",
+	"05": "Uttarakhand",
+	"06": "Haryana",
+	"07": "Delhi",
+	"08": "Rajasthan",
+	"09": "Uttar Pradesh",
+	"10": "Bihar",
+	"11": "Sikkim",
+	"12": "Arunachal Pradesh",
+	"13": "Nagaland",
+	"14": "Manipur",
+	"15": "Mizoram",
+	"16": "Tripura",
+	"17": "Meghalaya",
+	"18": "Assam",
+	"19": "West Bengal",
+	"20": "Jharkhand",
+	"21": "Odisha",
+	"22": "Chhattisgarh",
+	"23": "Madhya Pradesh",
+	"24": "Gujarat",
+	"26": "Dadra and Nagar Haveli and Daman and Diu",
+	"27": "Maharashtra",
+	"29": "Karnataka",
+	"30": "Goa",
+	"31": "Lakshadweep",
+	"32": "Kerala",
+	"33": "Tamil Nadu",
+	"34": "Puducherry",
+	"35": "Andaman and Nicobar Islands",
+	"36": "Telangana",
+	"37": "Andhra Pradesh",
+	"38": "Ladakh",
+}
+
+var gstStateAliases = map[string]string{
+	"uttaranchal":            "05",
+	"new delhi":              "07",
+	"nct of delhi":           "07",
+	"orissa":                 "21",
+	"daman and diu":          "26",
+	"dadra and nagar haveli": "26",
+	"pondicherry":            "34",
+}
+
+type GSTLine struct {
+	Item         events.OrderItem
+	HSNCode      string
+	Rate         decimal.Decimal
+	TaxableValue decimal.Decimal
+	CGST         decimal.Decimal
+	SGST         decimal.Decimal
+	IGST         decimal.Decimal
+}
+
+func (l GSTLine) Tax() decimal.Decimal {
+	return l.CGST.Add(l.SGST).Add(l.IGST)
+}
+
+type GSTSummary struct {
+	HSNCode      string
+	Rate         decimal.Decimal
+	TaxableValue decimal.Decimal
+	CGST         decimal.Decimal
+	SGST         decimal.Decimal
+	IGST         decimal.Decimal
+}
+
+func (s GSTSummary) Tax() decimal.Decimal {
+	return s.CGST.Add(s.SGST).Add(s.IGST)
+}
+
+type GSTBreakdown struct {
+	SellerGSTIN   string
+	BuyerGSTIN    string
+	SellerState   string
+	PlaceOfSupply string
+	InterState    bool
+	Lines         []GSTLine
+	Summary       []GSTSummary
+	TaxableValue  decimal.Decimal
+	CGST          decimal.Decimal
+	SGST          decimal.Decimal
+	IGST          decimal.Decimal
+}
+
+func isIndia(country string) bool {
+	switch strings.ToUpper(strings.TrimSpace(country)) {
+	case "IN", "IND", "INDIA":
+		return true
+	}
+	return false
+}
+
+func validGSTIN(gstin string) bool {
+	if !gstinPattern.MatchString(gstin) {
+		return false
+	}
+	_, ok := gstStates[gstin[:2]]
+	return ok
+}
+
+func gstStateCode(state string) (string, bool) {
+	state = strings.TrimSpace(state)
+	if _, ok := gstStates[state]; ok {
+		return state, true
+	}
+
+	key := strings.Join(strings.Fields(strings.ReplaceAll(strings.ToLower(state), "&", " and ")), " ")
+	for code, name := range gstStates {
+		if strings.ToLower(name) == key {
+			return code, true
+		}
+	}
+	code, ok := gstStateAliases[key]
+	return code, ok
+}
+
+func hasGSTDetails(event events.OrderPaidEvent) bool {
+	if event.Data.BuyerGSTIN != "" {
+		return true
+	}
+	for _, item := range event.Data.Items {
+		if item.HSNCode != "" || !item.TaxRate.IsZero() {
+			return true
+		}
+	}
+	return false
+}
+
+// computeGST returns nil when the order carries no GST details, so it keeps
+// the single tax line. An Indian destination whose state cannot be resolved is
+// an error rather than a silent inter-state supply.
+func computeGST(event events.OrderPaidEvent, sellerGSTIN string) (*GSTBreakdown, error) {
+	if sellerGSTIN == "" || !isIndia(event.Data.ShippingAddress.Country) || !hasGSTDetails(event) {
+		return nil, nil
+	}
+
+	currency := currencyFor(event.Data.Currency)
+	placeOfSupply, ok := gstStateCode(event.Data.ShippingAddress.State)
+	if !ok {
+		return nil, fmt.Errorf("resolve place of supply: unknown Indian state %q", event.Data.ShippingAddress.State)
+	}
+
+	b := &GSTBreakdown{
+		SellerGSTIN:   sellerGSTIN,
+		BuyerGSTIN:    event.Data.BuyerGSTIN,
+		SellerState:   sellerGSTIN[:2],
+		PlaceOfSupply: placeOfSupply,
+		InterState:    placeOfSupply != sellerGSTIN[:2],
+	}
+
+	summary := make(map[string]*GSTSummary)
+	var keys []string
+
+	for _, item := range event.Data.Items {
+		line := GSTLine{
+			Item:         item,
+			HSNCode:      item.HSNCode,
+			Rate:         item.TaxRate,
+			TaxableValue: currency.LineTotal(item.Price, item.Quantity),
+		}
+		tax := currency.Round(line.TaxableValue.Mul(item.TaxRate).Div(hundred))
+		if b.InterState {
+			line.IGST = tax
+		} else {
+			line.CGST = currency.Round(tax.Div(two))
+			line.SGST = tax.Sub(line.CGST)
+		}
+		b.Lines = append(b.Lines, line)
+
+		b.TaxableValue = b.TaxableValue.Add(line.TaxableValue)
+		b.CGST = b.CGST.Add(line.CGST)
+		b.SGST = b.SGST.Add(line.SGST)
+		b.IGST = b.IGST.Add(line.IGST)
+
+		key := line.HSNCode + "|" + line.Rate.String()
+		s, ok := summary[key]
+		if !ok {
+			s = &GSTSummary{HSNCode: line.HSNCode, Rate: line.Rate}
+			summary[key] = s
+			keys = append(keys, key)
+		}
+		s.TaxableValue = s.TaxableValue.Add(line.TaxableValue)
+		s.CGST = s.CGST.Add(line.CGST)
+		s.SGST = s.SGST.Add(line.SGST)
+		s.IGST = s.IGST.Add(line.IGST)
+	}
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		b.Summary = append(b.Summary, *summary[key])
+	}
+
+	return b, nil
+}
+
+func (b *GSTBreakdown) Tax() decimal.Decimal {
+	return b.CGST.Add(b.SGST).Add(b.IGST)
+}
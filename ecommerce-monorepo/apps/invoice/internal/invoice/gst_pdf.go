@@ -0,0 +1,186 @@
+package invoice
+
+import (
+	"fmt"
+
+	"github.com/tomarrohitt/invoice-go/internal/events"
+)
+
+func (g *PDFGenerator) taxBreakdown(event events.OrderPaidEvent) (*GSTBreakdown, error) {
+	return computeGST(event, g.Branding.GSTIN)
+}
+
+func (g *PDFGenerator) generateGSTParties(d *document, event events.OrderPaidEvent, gst *GSTBreakdown) {
+	placeOfSupply := event.Data.ShippingAddress.State
+	if name, ok := gstStates[gst.PlaceOfSupply]; ok {
+		placeOfSupply = fmt.Sprintf("%s (%s)", name, gst.PlaceOfSupply)
+	}
+
+	d.SetFont(fontFamily, "", 9)
+	d.SetTextColor(g.TextColor[0], g.TextColor[1], g.TextColor[2])
+	if gst.BuyerGSTIN != "" {
+		d.cellAt(110, 68, 80, 5, d.T("gstin", gst.BuyerGSTIN), "L")
+	}
+	d.cellAt(110, 74, 80, 5, d.T("placeOfSupply", placeOfSupply), "L")
+}
+
+func (g *PDFGenerator) generateGSTTable(d *document, gst *GSTBreakdown, currency Currency) {
+	d.SetY(125)
+	g.generateGSTTableHeader(d, gst)
+
+	for i, line := range gst.Lines {
+		if ensureSpace(d.Fpdf, tableRowHeight) {
+			g.generateGSTTableHeader(d, gst)
+		}
+
+		fill := i%2 == 0
+		if fill {
+			d.SetFillColor(g.GrayColor[0], g.GrayColor[1], g.GrayColor[2])
+		} else {
+			d.SetFillColor(255, 255, 255)
+		}
+
+		cols := []column{
+			{50, line.Item.Name, "L"},
+			{18, line.HSNCode, "C"},
+			{12, fmt.Sprintf("%d", line.Item.Quantity), "C"},
+			{24, currency.Format(line.Item.Price), "R"},
+			{26, currency.Format(line.TaxableValue), "R"},
+			{12, line.Rate.String() + "%", "C"},
+		}
+		if gst.InterState {
+			cols = append(cols, column{38, currency.Format(line.IGST), "R"})
+		} else {
+			cols = append(cols,
+				column{19, currency.Format(line.CGST), "R"},
+				column{19, currency.Format(line.SGST), "R"},
+			)
+		}
+		d.row(15, tableRowHeight, cols, true)
+	}
+}
+
+func (g *PDFGenerator) generateGSTTableHeader(d *document, gst *GSTBreakdown) {
+	d.SetFillColor(g.PrimaryColor[0], g.PrimaryColor[1], g.PrimaryColor[2])
+	d.SetTextColor(255, 255, 255)
+	d.SetFont(fontFamily, "B", 8)
+
+	cols := []column{
+		{50, d.T("description"), "L"},
+		{18, d.T("hsnSac"), "C"},
+		{12, d.T("qty"), "C"},
+		{24, d.T("price"), "R"},
+		{26, d.T("taxableValue"), "R"},
+		{12, d.T("gstRate"), "C"},
+	}
+	if gst.InterState {
+		cols = append(cols, column{38, d.T("igst"), "R"})
+	} else {
+		cols = append(cols,
+			column{19, d.T("cgst"), "R"},
+			column{19, d.T("sgst"), "R"},
+		)
+	}
+	d.row(15, tableRowHeight, cols, true)
+
+	d.SetTextColor(g.TextColor[0], g.TextColor[1], g.TextColor[2])
+	d.SetFont(fontFamily, "", 8)
+}
+
+func (g *PDFGenerator) generateGSTTotals(d *document, event events.OrderPaidEvent, gst *GSTBreakdown, currency Currency) {
+	ensureSpace(d.Fpdf, 2+4*totalsRowHeight)
+	d.Ln(2)
+
+	var startX float64 = 125
+	var labelWidth float64 = 35
+	var valueWidth float64 = 35
+
+	d.SetFont(fontFamily, "", 10)
+	d.SetTextColor(g.TextColor[0], g.TextColor[1], g.TextColor[2])
+	d.row(startX, totalsRowHeight, []column{
+		{labelWidth, d.T("taxableTotal"), "L"},
+		{valueWidth, currency.Format(gst.TaxableValue), "R"},
+	}, false)
+	if gst.InterState {
+		d.row(startX, totalsRowHeight, []column{
+			{labelWidth, d.T("igstTotal"), "L"},
+			{valueWidth, currency.Format(gst.IGST), "R"},
+		}, false)
+	} else {
+		d.row(startX, totalsRowHeight, []column{
+			{labelWidth, d.T("cgstTotal"), "L"},
+			{valueWidth, currency.Format(gst.CGST), "R"},
+		}, false)
+		d.row(startX, totalsRowHeight, []column{
+			{labelWidth, d.T("sgstTotal"), "L"},
+			{valueWidth, currency.Format(gst.SGST), "R"},
+		}, false)
+	}
+
+	d.SetDrawColor(g.GrayColor[0], g.GrayColor[1], g.GrayColor[2])
+	d.line(startX, d.GetY(), startX+labelWidth+valueWidth)
+
+	d.SetFont(fontFamily, "B", 10)
+	d.SetTextColor(g.PrimaryColor[0], g.PrimaryColor[1], g.PrimaryColor[2])
+	d.row(startX, totalsRowHeight, []column{
+		{labelWidth, d.T("total"), "L"},
+		{valueWidth, currency.Format(event.Data.TotalAmount), "R"},
+	}, false)
+}
+
+func (g *PDFGenerator) generateGSTSummary(d *document, gst *GSTBreakdown, currency Currency) {
+	ensureSpace(d.Fpdf, 6+8+2*totalsRowHeight)
+	d.Ln(6)
+
+	d.SetFont(fontFamily, "B", 10)
+	d.SetTextColor(g.PrimaryColor[0], g.PrimaryColor[1], g.PrimaryColor[2])
+	d.row(15, 8, []column{{180, d.T("taxSummary"), "L"}}, false)
+	g.generateGSTSummaryHeader(d, gst)
+
+	for _, s := range gst.Summary {
+		if ensureSpace(d.Fpdf, totalsRowHeight) {
+			g.generateGSTSummaryHeader(d, gst)
+		}
+		d.row(15, totalsRowHeight, g.gstSummaryColumns(gst, s.HSNCode, currency.Format(s.TaxableValue), s.Rate.String()+"%",
+			currency.Format(s.CGST), currency.Format(s.SGST), currency.Format(s.IGST), currency.Format(s.Tax())), false)
+	}
+
+	ensureSpace(d.Fpdf, totalsRowHeight)
+	d.SetDrawColor(g.GrayColor[0], g.GrayColor[1], g.GrayColor[2])
+	d.line(15, d.GetY(), 195)
+	d.SetFont(fontFamily, "B", 8)
+	d.row(15, totalsRowHeight, g.gstSummaryColumns(gst, d.T("total"), currency.Format(gst.TaxableValue), "",
+		currency.Format(gst.CGST), currency.Format(gst.SGST), currency.Format(gst.IGST), currency.Format(gst.Tax())), false)
+}
+
+func (g *PDFGenerator) generateGSTSummaryHeader(d *document, gst *GSTBreakdown) {
+	d.SetFillColor(g.PrimaryColor[0], g.PrimaryColor[1], g.PrimaryColor[2])
+	d.SetTextColor(255, 255, 255)
+	d.SetFont(fontFamily, "B", 8)
+
+	d.row(15, totalsRowHeight, g.gstSummaryColumns(gst, d.T("hsnSac"), d.T("taxableValue"), d.T("gstRate"),
+		d.T("cgst"), d.T("sgst"), d.T("igst"), d.T("totalTax")), true)
+
+	d.SetTextColor(g.TextColor[0], g.TextColor[1], g.TextColor[2])
+	d.SetFont(fontFamily, "", 8)
+}
+
+func (g *PDFGenerator) gstSummaryColumns(gst *GSTBreakdown, hsn, taxable, rate, cgst, sgst, igst, total string) []column {
+	if gst.InterState {
+		return []column{
+			{30, hsn, "L"},
+			{50, taxable, "R"},
+			{20, rate, "C"},
+			{40, igst, "R"},
+			{40, total, "R"},
+		}
+	}
+	return []column{
+		{30, hsn, "L"},
+		{40, taxable, "R"},
+		{20, rate, "C"},
+		{30, cgst, "R"},
+		{30, sgst, "R"},
+		{30, total, "R"},
+	}
+}
@@ -0,0 +1,234 @@
+package invoice
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tomarrohitt/invoice-go/internal/events"
+)
+
+const testSellerGSTIN = "29AAACR5055K1Z5"
+
+func TestComputeGST(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(*events.OrderPaidEvent)
+		wantNil bool
+		wantErr bool
+		cgst    string
+		igst    string
+	}{
+		{
+			name:   "intra-state supply splits cgst and sgst",
+			mutate: func(e *events.OrderPaidEvent) {},
+			cgst:   "70",
+			igst:   "0",
+		},
+		{
+			name: "inter-state supply charges igst",
+			mutate: func(e *events.OrderPaidEvent) {
+				e.Data.ShippingAddress.State = "Tamil Nadu"
+			},
+			cgst: "0",
+			igst: "140",
+		},
+		{
+			name: "no gst details keeps the single tax line",
+			mutate: func(e *events.OrderPaidEvent) {
+				e.Data.BuyerGSTIN = ""
+				e.Data.ShippingAddress.State = "TC"
+				for i := range e.Data.Items {
+					e.Data.Items[i].HSNCode = ""
+					e.Data.Items[i].TaxRate = decimal.Zero
+				}
+				e.Data.TaxedAmount = decimal.NewFromInt(2)
+				e.Data.TotalAmount = decimal.NewFromInt(1502)
+			},
+			wantNil: true,
+		},
+		{
+			name: "shipped outside india",
+			mutate: func(e *events.OrderPaidEvent) {
+				e.Data.ShippingAddress.Country = "US"
+			},
+			wantNil: true,
+		},
+		{
+			name: "unknown state",
+			mutate: func(e *events.OrderPaidEvent) {
+				e.Data.ShippingAddress.State = "TC"
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty state",
+			mutate: func(e *events.OrderPaidEvent) {
+				e.Data.ShippingAddress.State = ""
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := validGSTOrderPaid()
+			tt.mutate(&event)
+
+			gst, err := computeGST(event, testSellerGSTIN)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", gst)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantNil {
+				if gst != nil {
+					t.Fatalf("expected no GST breakdown, got %+v", gst)
+				}
+				return
+			}
+			if gst == nil {
+				t.Fatal("expected a GST breakdown")
+			}
+			if !gst.CGST.Equal(decimal.RequireFromString(tt.cgst)) || !gst.IGST.Equal(decimal.RequireFromString(tt.igst)) {
+				t.Errorf("got CGST %s IGST %s, want CGST %s IGST %s", gst.CGST, gst.IGST, tt.cgst, tt.igst)
+			}
+			if !gst.Tax().Equal(event.Data.TaxedAmount) {
+				t.Errorf("GST total %s does not match taxed amount %s", gst.Tax(), event.Data.TaxedAmount)
+			}
+		})
+	}
+}
+
+func TestRenderWithoutGSTDetailsUsesSingleTaxLine(t *testing.T) {
+	g := testGenerator(t)
+	g.Branding.GSTIN = testSellerGSTIN
+
+	event := validGSTOrderPaid()
+	event.Data.BuyerGSTIN = ""
+	event.Data.ShippingAddress.State = "TC"
+	for i := range event.Data.Items {
+		event.Data.Items[i].HSNCode = ""
+		event.Data.Items[i].TaxRate = decimal.Zero
+	}
+	event.Data.TaxedAmount = decimal.NewFromInt(2)
+	event.Data.TotalAmount = decimal.NewFromInt(1502)
+
+	d, err := g.render(event, g.Branding.GSTIN, "INV/2026/000001", time.Date(2026, time.March, 5, 10, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.SetCompression(false)
+	pdf, err := output(d.Fpdf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	layout := textLayout(t, pdf)
+	if strings.Contains(layout, "IGST") || strings.Contains(layout, "CGST") {
+		t.Errorf("rendered a GST table for an order without GST details:\n%s", layout)
+	}
+	if !strings.Contains(layout, "Tax:") {
+		t.Errorf("missing the single tax line:\n%s", layout)
+	}
+
+	gst, err := g.taxBreakdown(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, billing := snapshotFromEvent(event, gst); billing.SellerGSTIN != "" || billing.PlaceOfSupply != "" {
+		t.Errorf("snapshot recorded GST details: %+v", billing)
+	}
+}
+
+func TestRenderRejectsUnresolvedPlaceOfSupply(t *testing.T) {
+	g := testGenerator(t)
+	g.Branding.GSTIN = testSellerGSTIN
+
+	event := validGSTOrderPaid()
+	event.Data.ShippingAddress.State = ""
+
+	if _, err := g.Generate(event, "INV/2026/000001", time.Now()); err == nil {
+		t.Fatal("expected an error for an unresolved place of supply")
+	}
+}
+
+func TestBrandingContactLinesFitHeader(t *testing.T) {
+	b := Branding{
+		LegalName:    "Acme Supplies",
+		Address:      []string{"1 Market Street", "Indiranagar", "Bengaluru 560038"},
+		TaxID:        "AAACR5055K",
+		GSTIN:        testSellerGSTIN,
+		Phone:        "+91 80 4000 0000",
+		Email:        "billing@acme.test",
+		PrimaryColor: "#9333EA",
+		TextColor:    "#1F2937",
+		FillColor:    "#F3F4F6",
+	}
+	if _, err := LoadBranding("", b); err == nil {
+		t.Fatal("expected seven contact lines to be rejected")
+	}
+
+	b.Phone = ""
+	if _, err := LoadBranding("", b); err != nil {
+		t.Fatalf("six contact lines should fit: %v", err)
+	}
+}
+
+func TestItemCreditAmountIncludesTaxShare(t *testing.T) {
+	currency := currencyFor("INR")
+
+	event := validGSTOrderPaid()
+	gst, err := computeGST(event, testSellerGSTIN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, billing := snapshotFromEvent(event, gst)
+	if got := itemCreditAmount(event.Data.Items, items, billing, currency); !got.Equal(event.Data.TotalAmount) {
+		t.Errorf("refunding every item of a GST invoice credits %s, want the invoice total %s", got, event.Data.TotalAmount)
+	}
+
+	event = orderWithItems(3)
+	event.Data.TaxedAmount = decimal.RequireFromString("1.50")
+	event.Data.TotalAmount = event.Data.Subtotal.Add(event.Data.TaxedAmount)
+	items, billing = snapshotFromEvent(event, nil)
+	if got := itemCreditAmount(event.Data.Items, items, billing, currency); !got.Equal(event.Data.TotalAmount) {
+		t.Errorf("refunding every item of a single-tax invoice credits %s, want the invoice total %s", got, event.Data.TotalAmount)
+	}
+}
+
+func TestVoidCopyUsesSnapshotSellerGSTIN(t *testing.T) {
+	g := testGenerator(t)
+	g.Branding.GSTIN = "33AAACR5055K1Z5"
+
+	layout := func(sellerGSTIN string) string {
+		t.Helper()
+		d, err := g.renderVoid(validGSTOrderPaid(), sellerGSTIN, "INV/2026/000001", time.Date(2026, time.March, 5, 10, 0, 0, 0, time.UTC), "Order cancelled")
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.SetCompression(false)
+		pdf, err := output(d.Fpdf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return textLayout(t, pdf)
+	}
+
+	issued := layout(testSellerGSTIN)
+	if !strings.Contains(issued, "CGST") || strings.Contains(issued, "IGST") {
+		t.Errorf("void copy did not keep the intra-state split of the original seller GSTIN:\n%s", issued)
+	}
+	if !strings.Contains(issued, testSellerGSTIN) || strings.Contains(issued, g.Branding.GSTIN) {
+		t.Errorf("void copy header does not show the original seller GSTIN:\n%s", issued)
+	}
+
+	if noGST := layout(""); strings.Contains(noGST, "CGST") || strings.Contains(noGST, "IGST") {
+		t.Errorf("void copy of an invoice issued without GST rendered a GST table:\n%s", noGST)
+	}
+}
@@ -50,6 +50,11 @@ func (h *Handler) DownloadInvoice(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if inv.PDFURL == "" {
+		http.Error(w, "Invoice not ready", http.StatusNotFound)
+		return
+	}
+
 	fileKey := inv.PDFURL
 
 	secureURL, err := h.s3.GetSignedDownloadURL(ctx, fileKey)
@@ -60,6 +65,7 @@ func (h *Handler) DownloadInvoice(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
-		"url": secureURL,
+		"url":           secureURL,
+		"invoiceNumber": inv.DisplayNumber(),
 	})
 }
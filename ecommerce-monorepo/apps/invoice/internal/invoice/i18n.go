@@ -0,0 +1,196 @@
+package invoice
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+//go:embed locales/*.json
+var catalogFiles embed.FS
+
+const defaultLocale = "en"
+
+var countryLocales = map[string]string{
+	"IN":             "en-IN",
+	"INDIA":          "en-IN",
+	"CN":             "zh-CN",
+	"CHINA":          "zh-CN",
+	"JP":             "ja-JP",
+	"JAPAN":          "ja-JP",
+	"AE":             "ar-AE",
+	"UAE":            "ar-AE",
+	"SA":             "ar-SA",
+	"SAUDI ARABIA":   "ar-SA",
+	"IL":             "he-IL",
+	"ISRAEL":         "he-IL",
+	"US":             "en-US",
+	"USA":            "en-US",
+	"UNITED STATES":  "en-US",
+	"GB":             "en-GB",
+	"UK":             "en-GB",
+	"UNITED KINGDOM": "en-GB",
+	"DE":             "de-DE",
+	"GERMANY":        "de-DE",
+	"FR":             "fr-FR",
+	"FRANCE":         "fr-FR",
+	"ES":             "es-ES",
+	"SPAIN":          "es-ES",
+	"MX":             "es-MX",
+	"MEXICO":         "es-MX",
+}
+
+type catalog struct {
+	RTL        bool              `json:"rtl"`
+	DateFormat string            `json:"dateFormat"`
+	Months     []string          `json:"months"`
+	Messages   map[string]string `json:"messages"`
+}
+
+func (c catalog) text() string {
+	var b strings.Builder
+	for _, month := range c.Months {
+		b.WriteString(month)
+	}
+	for _, msg := range c.Messages {
+		b.WriteString(msg)
+	}
+	return b.String()
+}
+
+type Catalogs struct {
+	catalogs map[string]catalog
+}
+
+// LoadCatalogs reads the bundled catalogs and skips any whose labels the
+// loaded fonts cannot draw, so those locales fall back to English instead of
+// rendering missing glyphs.
+func LoadCatalogs(fonts *FontSet) (*Catalogs, error) {
+	entries, err := fs.ReadDir(catalogFiles, "locales")
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Catalogs{catalogs: make(map[string]catalog)}
+	for _, entry := range entries {
+		data, err := fs.ReadFile(catalogFiles, path.Join("locales", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		var cat catalog
+		if err := json.Unmarshal(data, &cat); err != nil {
+			return nil, fmt.Errorf("parse catalog %s: %w", entry.Name(), err)
+		}
+		if len(cat.Months) != 0 && len(cat.Months) != 12 {
+			return nil, fmt.Errorf("catalog %s: expected 12 month names, got %d", entry.Name(), len(cat.Months))
+		}
+
+		name := strings.ToLower(strings.TrimSuffix(entry.Name(), ".json"))
+		text := cat.text()
+		if i := strings.IndexFunc(text, needsShaping); i >= 0 {
+			r, _ := utf8.DecodeRuneInString(text[i:])
+			log.Printf("[Invoice] Disabling locale %s: %q needs text shaping, which the PDF renderer does not do", name, r)
+			continue
+		}
+		if r, ok := fonts.firstUncovered(text); ok {
+			log.Printf("[Invoice] Disabling locale %s: no font has a glyph for %q", name, r)
+			continue
+		}
+		c.catalogs[name] = cat
+	}
+
+	base, ok := c.catalogs[defaultLocale]
+	if !ok {
+		return nil, fmt.Errorf("catalog %s is missing", defaultLocale)
+	}
+	for name, cat := range c.catalogs {
+		for key := range cat.Messages {
+			if _, ok := base.Messages[key]; !ok {
+				return nil, fmt.Errorf("catalog %s: unknown message %q", name, key)
+			}
+		}
+	}
+
+	return c, nil
+}
+
+type Localizer struct {
+	locale     string
+	rtl        bool
+	dateFormat string
+	months     []string
+	messages   map[string]string
+}
+
+func (c *Catalogs) Localizer(locale string) *Localizer {
+	l := &Localizer{
+		locale:   locale,
+		messages: make(map[string]string),
+	}
+
+	tag := strings.ToLower(locale)
+	chain := []string{defaultLocale}
+	if lang, _, found := strings.Cut(tag, "-"); found {
+		chain = append(chain, lang)
+	}
+	chain = append(chain, tag)
+
+	for _, name := range chain {
+		cat, ok := c.catalogs[name]
+		if !ok {
+			continue
+		}
+		l.rtl = l.rtl || cat.RTL
+		if cat.DateFormat != "" {
+			l.dateFormat = cat.DateFormat
+		}
+		if len(cat.Months) == 12 {
+			l.months = cat.Months
+		}
+		for key, msg := range cat.Messages {
+			l.messages[key] = msg
+		}
+	}
+
+	return l
+}
+
+func (l *Localizer) T(key string, args ...any) string {
+	msg, ok := l.messages[key]
+	if !ok {
+		msg = key
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+func (l *Localizer) FormatDate(t time.Time) string {
+	return strings.NewReplacer(
+		"{dd}", fmt.Sprintf("%02d", t.Day()),
+		"{d}", strconv.Itoa(t.Day()),
+		"{mm}", fmt.Sprintf("%02d", int(t.Month())),
+		"{month}", l.months[t.Month()-1],
+		"{yyyy}", strconv.Itoa(t.Year()),
+	).Replace(l.dateFormat)
+}
+
+func resolveLocale(locale, country string) string {
+	locale = strings.ReplaceAll(strings.TrimSpace(locale), "_", "-")
+	if locale != "" {
+		return locale
+	}
+	if locale := countryLocales[strings.ToUpper(strings.TrimSpace(country))]; locale != "" {
+		return locale
+	}
+	return defaultLocale
+}
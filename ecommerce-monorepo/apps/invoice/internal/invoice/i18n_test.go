@@ -0,0 +1,52 @@
+package invoice
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+func TestCatalogNeedsADrawableScript(t *testing.T) {
+	bundled, err := LoadFonts(FontConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	catalogs, err := LoadCatalogs(bundled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := catalogs.catalogs["hi"]; ok {
+		t.Fatal("hi catalog enabled without a Devanagari font")
+	}
+	if got := catalogs.Localizer("hi-IN").T("date"); got != "Date:" {
+		t.Errorf("hi-IN without a Devanagari font: got %q, want the English label", got)
+	}
+	for _, locale := range []string{"ar", "he", "de"} {
+		if _, ok := catalogs.catalogs[locale]; !ok {
+			t.Errorf("%s catalog disabled although DejaVu covers it", locale)
+		}
+	}
+
+	data, err := catalogFiles.ReadFile("locales/hi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hi catalog
+	if err := json.Unmarshal(data, &hi); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	writeCoverageFont(t, filepath.Join(dir, "CoverageTest.ttf"), hi.text())
+	withDevanagari, err := LoadFonts(FontConfig{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	catalogs, err = LoadCatalogs(withDevanagari)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := catalogs.Localizer("hi-IN").T("date"); got != "Date:" {
+		t.Errorf("hi-IN needs shaping even with a Devanagari font: got %q, want the English label", got)
+	}
+}
@@ -0,0 +1,108 @@
+package invoice
+
+import (
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+const defaultCurrency = "USD"
+
+type Currency struct {
+	Code           string
+	MinorUnits     int32
+	Prefix         string
+	Suffix         string
+	DecimalSep     string
+	GroupSep       string
+	IndianGrouping bool
+}
+
+var currencies = map[string]Currency{
+	"USD": {Code: "USD", MinorUnits: 2, Prefix: "$", DecimalSep: ".", GroupSep: ","},
+	"CAD": {Code: "CAD", MinorUnits: 2, Prefix: "CA$", DecimalSep: ".", GroupSep: ","},
+	"AUD": {Code: "AUD", MinorUnits: 2, Prefix: "A$", DecimalSep: ".", GroupSep: ","},
+	"SGD": {Code: "SGD", MinorUnits: 2, Prefix: "S$", DecimalSep: ".", GroupSep: ","},
+	"GBP": {Code: "GBP", MinorUnits: 2, Prefix: "£", DecimalSep: ".", GroupSep: ","},
+	"EUR": {Code: "EUR", MinorUnits: 2, Suffix: " €", DecimalSep: ",", GroupSep: "."},
+	"CHF": {Code: "CHF", MinorUnits: 2, Prefix: "CHF ", DecimalSep: ".", GroupSep: "'"},
+	"INR": {Code: "INR", MinorUnits: 2, Prefix: "₹", DecimalSep: ".", GroupSep: ",", IndianGrouping: true},
+	"JPY": {Code: "JPY", MinorUnits: 0, Prefix: "¥", DecimalSep: ".", GroupSep: ","},
+	"KRW": {Code: "KRW", MinorUnits: 0, Prefix: "₩", DecimalSep: ".", GroupSep: ","},
+	"CNY": {Code: "CNY", MinorUnits: 2, Prefix: "CN¥", DecimalSep: ".", GroupSep: ","},
+	"AED": {Code: "AED", MinorUnits: 2, Prefix: "AED ", DecimalSep: ".", GroupSep: ","},
+	"SAR": {Code: "SAR", MinorUnits: 2, Prefix: "SAR ", DecimalSep: ".", GroupSep: ","},
+	"ILS": {Code: "ILS", MinorUnits: 2, Prefix: "₪", DecimalSep: ".", GroupSep: ","},
+}
+
+func normalizeCurrency(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return defaultCurrency
+	}
+	return code
+}
+
+func lookupCurrency(code string) (Currency, bool) {
+	c, ok := currencies[normalizeCurrency(code)]
+	return c, ok
+}
+
+func currencyFor(code string) Currency {
+	if c, ok := lookupCurrency(code); ok {
+		return c
+	}
+	code = normalizeCurrency(code)
+	return Currency{Code: code, MinorUnits: 2, Prefix: code + " ", DecimalSep: ".", GroupSep: ","}
+}
+
+func (c Currency) Round(d decimal.Decimal) decimal.Decimal {
+	return d.Round(c.MinorUnits)
+}
+
+func (c Currency) LineTotal(price decimal.Decimal, quantity int) decimal.Decimal {
+	return c.Round(price.Mul(decimal.NewFromInt(int64(quantity))))
+}
+
+func (c Currency) String(d decimal.Decimal) string {
+	return c.Round(d).StringFixed(c.MinorUnits)
+}
+
+func (c Currency) Format(d decimal.Decimal) string {
+	rounded := c.Round(d)
+
+	sign := ""
+	if rounded.IsNegative() {
+		sign = "-"
+		rounded = rounded.Neg()
+	}
+
+	whole, frac, _ := strings.Cut(rounded.StringFixed(c.MinorUnits), ".")
+	number := c.group(whole)
+	if frac != "" {
+		number += c.DecimalSep + frac
+	}
+
+	return sign + c.Prefix + number + c.Suffix
+}
+
+func (c Currency) group(digits string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+
+	head, tail := digits[:len(digits)-3], digits[len(digits)-3:]
+	size := 3
+	if c.IndianGrouping {
+		size = 2
+	}
+
+	var groups []string
+	for len(head) > size {
+		groups = append([]string{head[len(head)-size:]}, groups...)
+		head = head[:len(head)-size]
+	}
+	groups = append([]string{head}, groups...)
+
+	return strings.Join(append(groups, tail), c.GroupSep)
+}
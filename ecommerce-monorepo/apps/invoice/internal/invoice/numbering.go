@@ -0,0 +1,161 @@
+package invoice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type NumberingConfig struct {
+	Prefix               string
+	CreditNotePrefix     string
+	FiscalYearStartMonth time.Month
+	Location             *time.Location
+}
+
+type invoiceNumber struct {
+	Value      string
+	Series     string
+	FiscalYear int
+}
+
+// fiscalYear reads the calendar date in the business timezone, so an invoice
+// issued just after midnight local time on the first day of the fiscal year
+// is numbered in the new year even while it is still the day before in UTC.
+func (c NumberingConfig) fiscalYear(t time.Time) int {
+	if c.Location != nil {
+		t = t.In(c.Location)
+	}
+	if t.Month() < c.FiscalYearStartMonth {
+		return t.Year() - 1
+	}
+	return t.Year()
+}
+
+func (c NumberingConfig) fiscalYearLabel(year int) string {
+	if c.FiscalYearStartMonth <= time.January {
+		return fmt.Sprintf("%d", year)
+	}
+	return fmt.Sprintf("%d-%02d", year, (year+1)%100)
+}
+
+func (c NumberingConfig) format(series string, year int, seq int64) string {
+	return fmt.Sprintf("%s/%s/%06d", series, c.fiscalYearLabel(year), seq)
+}
+
+func (r *Repository) allocateNumber(ctx context.Context, tx pgx.Tx, series string, issuedAt time.Time) (invoiceNumber, error) {
+	year := r.numbering.fiscalYear(issuedAt)
+
+	var seq int64
+	err := tx.QueryRow(ctx, `
+		INSERT INTO invoice_number_counters (series, fiscal_year, last_value)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (series, fiscal_year) DO UPDATE
+		SET last_value = invoice_number_counters.last_value + 1,
+			updated_at = NOW()
+		RETURNING last_value
+	`, series, year).Scan(&seq)
+	if err != nil {
+		return invoiceNumber{}, err
+	}
+
+	return invoiceNumber{
+		Value:      r.numbering.format(series, year, seq),
+		Series:     series,
+		FiscalYear: year,
+	}, nil
+}
+
+func (r *Repository) ReserveNumber(ctx context.Context, invoiceID string) (string, time.Time, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	var reserved *string
+	var reservedAt *time.Time
+	err = tx.QueryRow(ctx,
+		`SELECT invoice_number, issued_at FROM invoices WHERE id = $1 FOR UPDATE`,
+		invoiceID,
+	).Scan(&reserved, &reservedAt)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if reserved != nil && reservedAt != nil {
+		return *reserved, *reservedAt, nil
+	}
+
+	issuedAt := time.Now()
+	number, err := r.allocateNumber(ctx, tx, r.numbering.Prefix, issuedAt)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE invoices
+		SET invoice_number = $2, series = $3, fiscal_year = $4, issued_at = $5
+		WHERE id = $1
+	`, invoiceID, number.Value, number.Series, number.FiscalYear, issuedAt)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return number.Value, issuedAt, tx.Commit(ctx)
+}
+
+// ReserveCreditNoteNumber allocates the next number of the credit note series
+// for refundID, or returns the one an earlier attempt already reserved.
+func (r *Repository) ReserveCreditNoteNumber(ctx context.Context, refundID string) (string, time.Time, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	var reserved string
+	var reservedAt time.Time
+	err = tx.QueryRow(ctx,
+		`SELECT credit_note_number, issued_at FROM credit_note_numbers WHERE refund_id = $1`,
+		refundID,
+	).Scan(&reserved, &reservedAt)
+	if err == nil {
+		return reserved, reservedAt, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return "", time.Time{}, err
+	}
+
+	issuedAt := time.Now()
+	number, err := r.allocateNumber(ctx, tx, r.numbering.CreditNotePrefix, issuedAt)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO credit_note_numbers (refund_id, credit_note_number, series, fiscal_year, issued_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, refundID, number.Value, number.Series, number.FiscalYear, issuedAt)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return number.Value, issuedAt, tx.Commit(ctx)
+}
+
+func (inv *Invoice) DisplayNumber() string {
+	if inv.InvoiceNumber != nil {
+		return *inv.InvoiceNumber
+	}
+	return documentNumber("INV", inv.ID)
+}
+
+func (inv *Invoice) IssueDate() time.Time {
+	if inv.IssuedAt != nil {
+		return *inv.IssuedAt
+	}
+	return inv.CreatedAt
+}
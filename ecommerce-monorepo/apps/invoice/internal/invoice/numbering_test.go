@@ -0,0 +1,31 @@
+package invoice
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFiscalYearUsesBusinessTimezone(t *testing.T) {
+	ist := time.FixedZone("IST", 5*60*60+30*60)
+	numbering := NumberingConfig{Prefix: "INV", FiscalYearStartMonth: time.April, Location: ist}
+
+	tests := []struct {
+		at   time.Time
+		want int
+	}{
+		{time.Date(2026, time.March, 31, 18, 29, 59, 0, time.UTC), 2025},
+		{time.Date(2026, time.March, 31, 18, 30, 0, 0, time.UTC), 2026},
+		{time.Date(2026, time.March, 31, 23, 59, 0, 0, time.UTC), 2026},
+		{time.Date(2026, time.April, 1, 5, 29, 0, 0, ist), 2026},
+	}
+	for _, tt := range tests {
+		if got := numbering.fiscalYear(tt.at); got != tt.want {
+			t.Errorf("%s (%s IST): fiscal year %d, want %d", tt.at.UTC(), tt.at.In(ist).Format(time.DateTime), got, tt.want)
+		}
+	}
+
+	numbering.Location = nil
+	if got := numbering.fiscalYear(time.Date(2026, time.March, 31, 20, 0, 0, 0, time.UTC)); got != 2025 {
+		t.Errorf("without a business timezone: fiscal year %d, want 2025", got)
+	}
+}
@@ -10,31 +10,125 @@ import (
 	"github.com/tomarrohitt/invoice-go/internal/events"
 )
 
+const (
+	footerMargin    = 30
+	tableRowHeight  = 10
+	totalsRowHeight = 8
+)
+
 type PDFGenerator struct {
+	Branding     Branding
+	Fonts        *FontSet
+	Catalogs     *Catalogs
 	PrimaryColor []int
 	TextColor    []int
 	GrayColor    []int
 }
 
-func NewPDFGenerator() *PDFGenerator {
+func NewPDFGenerator(branding Branding, fonts *FontSet, catalogs *Catalogs) *PDFGenerator {
 	return &PDFGenerator{
-		PrimaryColor: []int{147, 51, 234},
-		TextColor:    []int{31, 41, 55},
-		GrayColor:    []int{243, 244, 246},
+		Branding:     branding,
+		Fonts:        fonts,
+		Catalogs:     catalogs,
+		PrimaryColor: branding.primary,
+		TextColor:    branding.text,
+		GrayColor:    branding.fill,
 	}
 }
 
-func (g *PDFGenerator) Generate(event events.OrderPaidEvent, invoiceID string) ([]byte, error) {
+func (g *PDFGenerator) Generate(event events.OrderPaidEvent, invoiceNumber string, issuedAt time.Time) ([]byte, error) {
+	d, err := g.render(event, g.Branding.GSTIN, invoiceNumber, issuedAt)
+	if err != nil {
+		return nil, err
+	}
+	return output(d.Fpdf)
+}
+
+// GenerateVoid re-renders an issued invoice with the seller GSTIN it was
+// issued under, which may differ from the one configured now.
+func (g *PDFGenerator) GenerateVoid(event events.OrderPaidEvent, sellerGSTIN, invoiceNumber string, issuedAt time.Time, reason string) ([]byte, error) {
+	d, err := g.renderVoid(event, sellerGSTIN, invoiceNumber, issuedAt, reason)
+	if err != nil {
+		return nil, err
+	}
+	return output(d.Fpdf)
+}
+
+func (g *PDFGenerator) renderVoid(event events.OrderPaidEvent, sellerGSTIN, invoiceNumber string, issuedAt time.Time, reason string) (*document, error) {
+	if sellerGSTIN != "" && sellerGSTIN != g.Branding.GSTIN {
+		issuedBy := *g
+		issuedBy.Branding.GSTIN = sellerGSTIN
+		g = &issuedBy
+	}
+
+	d, err := g.render(event, sellerGSTIN, invoiceNumber, issuedAt)
+	if err != nil {
+		return nil, err
+	}
+	g.generateVoidWatermark(d, reason)
+	return d, nil
+}
+
+func (g *PDFGenerator) render(event events.OrderPaidEvent, sellerGSTIN, invoiceNumber string, issuedAt time.Time) (*document, error) {
+	gst, err := computeGST(event, sellerGSTIN)
+	if err != nil {
+		return nil, err
+	}
+
+	d := g.newDocument(resolveLocale(event.Data.Locale, event.Data.BillingAddress.Country))
+
+	g.generateHeader(d, "invoiceNo")
+	g.generateInfoSection(d, event, invoiceNumber, issuedAt)
+	currency := currencyFor(event.Data.Currency)
+
+	if gst != nil {
+		g.generateGSTParties(d, event, gst)
+		g.generateGSTTable(d, gst, currency)
+		g.generateGSTTotals(d, event, gst, currency)
+		g.generateGSTSummary(d, gst, currency)
+		return d, nil
+	}
+
+	g.generateTable(d, event.Data.Items, currency)
+	g.generateTotals(d, event, currency)
+
+	return d, nil
+}
+
+func (g *PDFGenerator) newDocument(locale string) *document {
 	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AliasNbPages("")
+	families := g.Fonts.register(pdf, locale)
 	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, footerMargin)
+
+	d := &document{
+		Fpdf:      pdf,
+		Localizer: g.Catalogs.Localizer(locale),
+		fonts:     g.Fonts,
+		families:  families,
+		loaded:    make(map[string]bool),
+	}
+	pdf.SetFooterFunc(func() {
+		style, size := d.fontStyle, d.fontSize
+		g.generateFooter(d)
+		d.fontStyle, d.fontSize = style, size
+	})
 	pdf.AddPage()
+	return d
+}
 
-	g.generateHeader(pdf)
-	g.generateInfoSection(pdf, event, invoiceID)
-	g.generateTable(pdf, event)
-	g.generateTotals(pdf, event)
-	g.generateFooter(pdf)
+func ensureSpace(pdf *gofpdf.Fpdf, height float64) bool {
+	_, pageHeight := pdf.GetPageSize()
+	_, bottomMargin := pdf.GetAutoPageBreak()
+	if pdf.GetY()+height <= pageHeight-bottomMargin {
+		return false
+	}
+	pdf.AddPage()
+	return true
+}
 
+func output(pdf *gofpdf.Fpdf) ([]byte, error) {
 	var buf bytes.Buffer
 	if err := pdf.Output(&buf); err != nil {
 		return nil, err
@@ -42,162 +136,205 @@ func (g *PDFGenerator) Generate(event events.OrderPaidEvent, invoiceID string) (
 	return buf.Bytes(), nil
 }
 
-func (g *PDFGenerator) generateHeader(pdf *gofpdf.Fpdf) {
-	pdf.SetFont("Arial", "B", 24)
-	pdf.SetTextColor(g.PrimaryColor[0], g.PrimaryColor[1], g.PrimaryColor[2])
-	pdf.Cell(0, 10, "E-Commerce Co.")
-
-	pdf.SetFont("Arial", "", 9)
-	pdf.SetTextColor(g.TextColor[0], g.TextColor[1], g.TextColor[2])
-	pdf.SetXY(120, 25)
-	pdf.Cell(18, 5, "Invoice No:")
-	pdf.SetXY(120, 30)
-	pdf.Cell(18, 5, "Date:")
-
-	pdf.SetXY(15, 25)
-	pdf.SetFont("Arial", "", 9)
-	pdf.SetTextColor(g.TextColor[0], g.TextColor[1], g.TextColor[2])
-	pdf.Text(15, 30, "123 Cloud Avenue, Tech City")
-	pdf.Text(15, 35, "Phone: +1 (555) 123-4567")
-	pdf.Text(15, 40, "Email: support@ecommerce.com")
-	pdf.Ln(20)
-}
-
-func (g *PDFGenerator) generateInfoSection(pdf *gofpdf.Fpdf, event events.OrderPaidEvent, invoiceID string) {
-
-	shortID := fmt.Sprintf("%s-%s", invoiceID[:8], invoiceID[len(invoiceID)-12:])
-
-	pdf.SetFont("Arial", "", 9)
-	pdf.SetTextColor(g.TextColor[0], g.TextColor[1], g.TextColor[2])
-	pdf.SetXY(140, 25)
-	pdf.Cell(0, 5, fmt.Sprintf("INV-%s", strings.ToUpper(shortID)))
-	pdf.SetXY(140, 30)
-	pdf.Cell(0, 5, time.Now().Format("Jan 02, 2006"))
-
-	pdf.SetFillColor(g.GrayColor[0], g.GrayColor[1], g.GrayColor[2])
-	pdf.Rect(15, 55, 180, 30, "F")
-
-	pdf.SetXY(20, 60)
-	pdf.SetFont("Arial", "B", 10)
-	pdf.SetTextColor(g.PrimaryColor[0], g.PrimaryColor[1], g.PrimaryColor[2])
-	pdf.Cell(0, 5, "Bill To")
-
-	pdf.SetFont("Arial", "B", 9)
-	pdf.SetTextColor(g.TextColor[0], g.TextColor[1], g.TextColor[2])
-	pdf.SetXY(20, 68)
-	pdf.Cell(0, 5, event.Data.UserName)
-	pdf.SetFont("Arial", "", 9)
-	pdf.SetXY(20, 74)
-	pdf.Cell(0, 5, event.Data.UserEmail)
-
-	pdf.Rect(15, 90, 90, 30, "F")
-	pdf.SetXY(20, 95)
-	pdf.SetFont("Arial", "B", 10)
-	pdf.SetTextColor(g.PrimaryColor[0], g.PrimaryColor[1], g.PrimaryColor[2])
-	pdf.Cell(0, 5, "Shipping Address")
-
-	pdf.SetFont("Arial", "", 8)
-	pdf.SetTextColor(g.TextColor[0], g.TextColor[1], g.TextColor[2])
-	pdf.SetXY(20, 102)
-	pdf.Cell(0, 4, event.Data.ShippingAddress.Name)
-	pdf.SetXY(20, 106)
-	pdf.Cell(0, 4, event.Data.ShippingAddress.Street)
-	pdf.SetXY(20, 110)
-	pdf.Cell(0, 4, fmt.Sprintf("%s, %s %s", event.Data.ShippingAddress.City, event.Data.ShippingAddress.State, event.Data.ShippingAddress.ZipCode))
-	pdf.SetXY(20, 114)
-	pdf.Cell(0, 4, event.Data.ShippingAddress.Country)
-
-	pdf.Rect(105, 90, 90, 30, "F")
-	pdf.SetXY(110, 95)
-	pdf.SetFont("Arial", "B", 10)
-	pdf.SetTextColor(g.PrimaryColor[0], g.PrimaryColor[1], g.PrimaryColor[2])
-	pdf.Cell(0, 5, "Billing Address")
-
-	pdf.SetFont("Arial", "", 8)
-	pdf.SetTextColor(g.TextColor[0], g.TextColor[1], g.TextColor[2])
-	pdf.SetXY(110, 102)
-	pdf.Cell(0, 4, event.Data.BillingAddress.Name)
-	pdf.SetXY(110, 106)
-	pdf.Cell(0, 4, event.Data.BillingAddress.Street)
-	pdf.SetXY(110, 110)
-	pdf.Cell(0, 4, fmt.Sprintf("%s, %s %s", event.Data.BillingAddress.City, event.Data.BillingAddress.State, event.Data.BillingAddress.ZipCode))
-	pdf.SetXY(110, 114)
-	pdf.Cell(0, 4, event.Data.BillingAddress.Country)
-}
-
-func (g *PDFGenerator) generateTable(pdf *gofpdf.Fpdf, event events.OrderPaidEvent) {
-	pdf.SetXY(15, 125)
-	pdf.SetFillColor(g.PrimaryColor[0], g.PrimaryColor[1], g.PrimaryColor[2])
-	pdf.SetTextColor(255, 255, 255)
-	pdf.SetFont("Arial", "B", 10)
-
-	pdf.CellFormat(80, 10, "Description", "0", 0, "L", true, 0, "")
-	pdf.CellFormat(30, 10, "Qty", "0", 0, "C", true, 0, "")
-	pdf.CellFormat(40, 10, "Price", "0", 0, "C", true, 0, "")
-	pdf.CellFormat(30, 10, "Amount", "", 1, "R", true, 0, "")
-
-	pdf.SetTextColor(g.TextColor[0], g.TextColor[1], g.TextColor[2])
-	pdf.SetFont("Arial", "", 10)
-	for i, item := range event.Data.Items {
+func (g *PDFGenerator) generateHeader(d *document, numberLabel string) {
+	var nameOffset float64
+	if len(g.Branding.logo) > 0 {
+		opts := gofpdf.ImageOptions{ImageType: g.Branding.logoType}
+		info := d.RegisterImageOptionsReader("logo", opts, bytes.NewReader(g.Branding.logo))
+		if info != nil && info.Height() > 0 {
+			logoHeight := 10.0
+			logoWidth := logoHeight * info.Width() / info.Height()
+			d.ImageOptions("logo", d.mirrorX(15, logoWidth), 15, logoWidth, logoHeight, false, opts, 0, "")
+			nameOffset = logoWidth + 4
+		}
+	}
+
+	d.SetFont(fontFamily, "B", 24)
+	d.SetTextColor(g.PrimaryColor[0], g.PrimaryColor[1], g.PrimaryColor[2])
+	d.cellAt(15+nameOffset, 15, 180-nameOffset, 10, g.Branding.LegalName, "L")
+
+	d.SetFont(fontFamily, "", 9)
+	d.SetTextColor(g.TextColor[0], g.TextColor[1], g.TextColor[2])
+	d.cellAt(115, 25, 25, 5, d.T(numberLabel), "L")
+	d.cellAt(115, 30, 25, 5, d.T("date"), "L")
+
+	for i, line := range g.Branding.contactLines(d.Localizer) {
+		d.textAt(15, 30+float64(i)*4.5, line)
+	}
+}
+
+func documentNumber(prefix, id string) string {
+	shortID := fmt.Sprintf("%s-%s", id[:8], id[len(id)-12:])
+	return fmt.Sprintf("%s-%s", prefix, strings.ToUpper(shortID))
+}
+
+func (g *PDFGenerator) generateInfoSection(d *document, event events.OrderPaidEvent, invoiceNumber string, issuedAt time.Time) {
+	d.SetFont(fontFamily, "", 9)
+	d.SetTextColor(g.TextColor[0], g.TextColor[1], g.TextColor[2])
+	d.cellAt(140, 25, 55, 5, invoiceNumber, "L")
+	d.cellAt(140, 30, 55, 5, d.FormatDate(issuedAt), "L")
+
+	d.SetFillColor(g.GrayColor[0], g.GrayColor[1], g.GrayColor[2])
+	d.rect(15, 55, 180, 30, "F")
+
+	d.SetFont(fontFamily, "B", 10)
+	d.SetTextColor(g.PrimaryColor[0], g.PrimaryColor[1], g.PrimaryColor[2])
+	d.cellAt(20, 60, 170, 5, d.T("billTo"), "L")
+
+	d.SetFont(fontFamily, "B", 9)
+	d.SetTextColor(g.TextColor[0], g.TextColor[1], g.TextColor[2])
+	d.cellAt(20, 68, 170, 5, event.Data.UserName, "L")
+	d.SetFont(fontFamily, "", 9)
+	d.cellAt(20, 74, 170, 5, event.Data.UserEmail, "L")
+
+	g.generateAddress(d, 15, d.T("shippingAddress"), event.Data.ShippingAddress)
+	g.generateAddress(d, 105, d.T("billingAddress"), event.Data.BillingAddress)
+}
+
+func (g *PDFGenerator) generateAddress(d *document, x float64, title string, addr events.Address) {
+	d.SetFillColor(g.GrayColor[0], g.GrayColor[1], g.GrayColor[2])
+	d.rect(x, 90, 90, 30, "F")
+
+	d.SetFont(fontFamily, "B", 10)
+	d.SetTextColor(g.PrimaryColor[0], g.PrimaryColor[1], g.PrimaryColor[2])
+	d.cellAt(x+5, 95, 80, 5, title, "L")
+
+	d.SetFont(fontFamily, "", 8)
+	d.SetTextColor(g.TextColor[0], g.TextColor[1], g.TextColor[2])
+	d.cellAt(x+5, 102, 80, 4, addr.Name, "L")
+	d.cellAt(x+5, 106, 80, 4, addr.Street, "L")
+	d.cellAt(x+5, 110, 80, 4, fmt.Sprintf("%s, %s %s", addr.City, addr.State, addr.ZipCode), "L")
+	d.cellAt(x+5, 114, 80, 4, addr.Country, "L")
+}
+
+func (g *PDFGenerator) generateTable(d *document, items []events.OrderItem, currency Currency) {
+	d.SetY(125)
+	g.generateTableHeader(d)
+
+	for i, item := range items {
+		if ensureSpace(d.Fpdf, tableRowHeight) {
+			g.generateTableHeader(d)
+		}
+
 		fill := i%2 == 0
 		if fill {
-			pdf.SetFillColor(g.GrayColor[0], g.GrayColor[1], g.GrayColor[2])
+			d.SetFillColor(g.GrayColor[0], g.GrayColor[1], g.GrayColor[2])
 		} else {
-			pdf.SetFillColor(255, 255, 255)
+			d.SetFillColor(255, 255, 255)
 		}
 
-		lineTotal := item.Price * float64(item.Quantity)
-		pdf.CellFormat(80, 10, item.Name, "0", 0, "L", true, 0, "")
-		pdf.CellFormat(30, 10, fmt.Sprintf("%d", item.Quantity), "0", 0, "C", true, 0, "")
-		pdf.CellFormat(40, 10, fmt.Sprintf("$%.2f", item.Price), "0", 0, "C", true, 0, "")
-		pdf.CellFormat(30, 10, fmt.Sprintf("$%.2f", lineTotal), "", 1, "R", true, 0, "")
+		d.row(15, tableRowHeight, []column{
+			{80, item.Name, "L"},
+			{30, fmt.Sprintf("%d", item.Quantity), "C"},
+			{40, currency.Format(item.Price), "C"},
+			{30, currency.Format(currency.LineTotal(item.Price, item.Quantity)), "R"},
+		}, true)
 	}
 }
 
-func (g *PDFGenerator) generateTotals(pdf *gofpdf.Fpdf, event events.OrderPaidEvent) {
-	pdf.Ln(2)
+func (g *PDFGenerator) generateTableHeader(d *document) {
+	d.SetFillColor(g.PrimaryColor[0], g.PrimaryColor[1], g.PrimaryColor[2])
+	d.SetTextColor(255, 255, 255)
+	d.SetFont(fontFamily, "B", 10)
 
-	var startX float64 = 140
-	var labelWidth float64 = 35
-	var valueWidth float64 = 0
-
-	pdf.SetFont("Arial", "", 10)
-	pdf.SetTextColor(g.TextColor[0], g.TextColor[1], g.TextColor[2])
-	pdf.SetX(startX)
-	pdf.CellFormat(labelWidth, 8, "Subtotal:", "", 0, "L", false, 0, "")
-	pdf.CellFormat(valueWidth, 8, fmt.Sprintf("$%.2f", event.Data.Subtotal), "", 1, "R", false, 0, "")
+	d.row(15, tableRowHeight, []column{
+		{80, d.T("description"), "L"},
+		{30, d.T("qty"), "C"},
+		{40, d.T("price"), "C"},
+		{30, d.T("amount"), "R"},
+	}, true)
 
-	pdf.SetX(startX)
-	pdf.CellFormat(labelWidth, 8, "Tax:", "", 0, "L", false, 0, "")
-	pdf.CellFormat(valueWidth, 8, fmt.Sprintf("$%.2f", event.Data.TaxedAmount), "", 1, "R", false, 0, "")
+	d.SetTextColor(g.TextColor[0], g.TextColor[1], g.TextColor[2])
+	d.SetFont(fontFamily, "", 10)
+}
 
-	pdf.SetDrawColor(g.GrayColor[0], g.GrayColor[1], g.GrayColor[2])
-	pdf.Line(startX, pdf.GetY(), startX+labelWidth+valueWidth, pdf.GetY())
+func (g *PDFGenerator) generateTotals(d *document, event events.OrderPaidEvent, currency Currency) {
+	ensureSpace(d.Fpdf, 2+3*totalsRowHeight)
+	d.Ln(2)
 
-	pdf.SetFont("Arial", "B", 10)
-	pdf.SetTextColor(g.PrimaryColor[0], g.PrimaryColor[1], g.PrimaryColor[2])
-	pdf.SetX(startX)
-	pdf.CellFormat(labelWidth, 8, "Total Amount:", "", 0, "L", false, 0, "")
-	pdf.SetFont("Arial", "B", 10)
-	pdf.CellFormat(valueWidth, 8, fmt.Sprintf("$%.2f", event.Data.TotalAmount), "", 1, "R", false, 0, "")
+	var startX float64 = 125
+	var labelWidth float64 = 35
+	var valueWidth float64 = 35
+
+	d.SetFont(fontFamily, "", 10)
+	d.SetTextColor(g.TextColor[0], g.TextColor[1], g.TextColor[2])
+	d.row(startX, totalsRowHeight, []column{
+		{labelWidth, d.T("subtotal"), "L"},
+		{valueWidth, currency.Format(event.Data.Subtotal), "R"},
+	}, false)
+	d.row(startX, totalsRowHeight, []column{
+		{labelWidth, d.T("tax"), "L"},
+		{valueWidth, currency.Format(event.Data.TaxedAmount), "R"},
+	}, false)
+
+	d.SetDrawColor(g.GrayColor[0], g.GrayColor[1], g.GrayColor[2])
+	d.line(startX, d.GetY(), startX+labelWidth+valueWidth)
+
+	d.SetFont(fontFamily, "B", 10)
+	d.SetTextColor(g.PrimaryColor[0], g.PrimaryColor[1], g.PrimaryColor[2])
+	d.row(startX, totalsRowHeight, []column{
+		{labelWidth, d.T("total"), "L"},
+		{valueWidth, currency.Format(event.Data.TotalAmount), "R"},
+	}, false)
 }
 
-func (g *PDFGenerator) generateFooter(pdf *gofpdf.Fpdf) {
-	pdf.SetAutoPageBreak(false, 0)
+func (g *PDFGenerator) generateFooter(d *document) {
+	d.SetY(-25)
 
-	pdf.SetY(-25)
+	d.SetDrawColor(g.GrayColor[0], g.GrayColor[1], g.GrayColor[2])
+	d.SetLineWidth(0.2)
+	d.line(15, d.GetY(), 195)
 
-	pdf.SetDrawColor(g.GrayColor[0], g.GrayColor[1], g.GrayColor[2])
-	pdf.SetLineWidth(0.2)
-	pdf.Line(15, pdf.GetY(), 195, pdf.GetY())
+	d.Ln(2)
 
-	pdf.Ln(2)
+	d.SetFont(fontFamily, "I", 8)
+	d.SetTextColor(128, 128, 128)
 
-	pdf.SetFont("Arial", "I", 8)
-	pdf.SetTextColor(128, 128, 128)
+	footerText := g.Branding.FooterText
+	if footerText == "" {
+		footerText = d.T("thankYou")
+	}
+
+	contact := d.T("contact", g.Branding.Email)
+	if g.Branding.Website != "" {
+		contact = fmt.Sprintf("%s | %s", contact, g.Branding.Website)
+	}
+
+	d.cell(0, 5, footerText, 1, "C", false)
+	d.cell(0, 5, contact, 1, "C", false)
+	d.cell(0, 5, d.T("page", d.PageNo(), "{nb}"), 1, "C", false)
+}
 
-	pdf.CellFormat(0, 5, "Thank you for your business!", "", 1, "C", false, 0, "")
-	pdf.CellFormat(0, 5, "For questions, contact: support@ecommerce.com", "", 1, "C", false, 0, "")
+func (g *PDFGenerator) generateVoidWatermark(d *document, reason string) {
+	pageWidth, pageHeight := d.GetPageSize()
+	label := d.text(d.T("void"))
+
+	for page := 1; page <= d.PageCount(); page++ {
+		d.SetPage(page)
+		d.SetAutoPageBreak(false, 0)
+
+		d.SetAlpha(0.25, "Normal")
+		d.SetTextColor(220, 38, 38)
+		d.SetFont(fontFamily, "B", 120)
+		restore := d.useFontFor(label)
+		if width := d.GetStringWidth(label); width > 300 {
+			restore()
+			d.SetFont(fontFamily, "B", 120*300/width)
+			restore = d.useFontFor(label)
+		}
+		d.TransformBegin()
+		d.TransformRotate(45, pageWidth/2, pageHeight/2)
+		textWidth := d.GetStringWidth(label)
+		d.Text((pageWidth-textWidth)/2, pageHeight/2+15, label)
+		d.TransformEnd()
+		restore()
+		d.SetAlpha(1, "Normal")
+
+		if reason != "" {
+			d.SetFont(fontFamily, "B", 9)
+			d.cellAt(15, 45, 180, 6, d.T("voidReason", reason), "L")
+		}
 
-	pdf.SetAutoPageBreak(true, 15)
+		d.SetAutoPageBreak(true, footerMargin)
+	}
 }
@@ -0,0 +1,250 @@
+package invoice
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/shopspring/decimal"
+	"github.com/tomarrohitt/invoice-go/internal/events"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+var (
+	pageStreamPattern = regexp.MustCompile(`<</Length (\d+)>>\nstream\n`)
+	textRunPattern    = regexp.MustCompile(`(?s)BT ([\d.-]+) ([\d.-]+) Td \(((?:\\.|[^\\)])*)\) ?Tj ET`)
+)
+
+func testGenerator(t *testing.T) *PDFGenerator {
+	t.Helper()
+
+	branding, err := LoadBranding("", Branding{
+		LegalName:    "Acme Supplies",
+		Address:      []string{"1 Market Street", "Springfield"},
+		Phone:        "+1 555 0100",
+		Email:        "billing@acme.test",
+		PrimaryColor: "#9333EA",
+		TextColor:    "#1F2937",
+		FillColor:    "#F3F4F6",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fonts, err := LoadFonts(FontConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	catalogs, err := LoadCatalogs(fonts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewPDFGenerator(branding, fonts, catalogs)
+}
+
+func orderWithItems(n int) events.OrderPaidEvent {
+	currency := currencyFor("USD")
+
+	var event events.OrderPaidEvent
+	event.EventName = "order.paid"
+	event.Data.OrderID = "order-golden"
+	event.Data.UserID = "user-golden"
+	event.Data.UserName = "Jane Doe"
+	event.Data.UserEmail = "jane@example.test"
+	event.Data.Currency = currency.Code
+	event.Data.Locale = "en"
+	event.Data.ShippingAddress = events.Address{Name: "Jane Doe", Street: "42 Elm Road", City: "Portland", State: "OR", ZipCode: "97201", Country: "US"}
+	event.Data.BillingAddress = event.Data.ShippingAddress
+
+	subtotal := decimal.Zero
+	for i := 1; i <= n; i++ {
+		item := events.OrderItem{
+			ProductID: fmt.Sprintf("p%03d", i),
+			Name:      fmt.Sprintf("Item %03d", i),
+			Price:     decimal.New(int64(125*i), -2),
+			Quantity:  i%3 + 1,
+		}
+		event.Data.Items = append(event.Data.Items, item)
+		subtotal = subtotal.Add(currency.LineTotal(item.Price, item.Quantity))
+	}
+
+	event.Data.Subtotal = subtotal
+	event.Data.TaxedAmount = currency.Round(subtotal.Div(decimal.NewFromInt(10)))
+	event.Data.TotalAmount = subtotal.Add(event.Data.TaxedAmount)
+	return event
+}
+
+func TestInvoiceLayoutGolden(t *testing.T) {
+	g := testGenerator(t)
+	issuedAt := time.Date(2026, time.March, 5, 10, 0, 0, 0, time.UTC)
+
+	for _, n := range []int{1, 15, 200} {
+		t.Run(fmt.Sprintf("%d items", n), func(t *testing.T) {
+			d, err := g.render(orderWithItems(n), "", "INV/2026/000042", issuedAt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			d.SetCompression(false)
+
+			pdf, err := output(d.Fpdf)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			compareGolden(t, fmt.Sprintf("invoice_%d_items.golden", n), textLayout(t, pdf))
+		})
+	}
+}
+
+// textLayout lists every text run of an uncompressed PDF by page, so golden
+// files catch changes to pagination, repeated headers and positioning.
+func textLayout(t *testing.T, pdf []byte) string {
+	t.Helper()
+
+	var pages []string
+	for _, loc := range pageStreamPattern.FindAllSubmatchIndex(pdf, -1) {
+		length, err := strconv.Atoi(string(pdf[loc[2]:loc[3]]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		content := pdf[loc[1] : loc[1]+length]
+
+		runs := textRunPattern.FindAllSubmatch(content, -1)
+		if len(runs) == 0 {
+			continue
+		}
+
+		var page strings.Builder
+		for _, run := range runs {
+			fmt.Fprintf(&page, "%s %s %s\n", run[1], run[2], decodeTextRun(run[3]))
+		}
+		pages = append(pages, page.String())
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "pages: %d\n", len(pages))
+	for i, page := range pages {
+		fmt.Fprintf(&b, "\n--- page %d ---\n%s", i+1, page)
+	}
+	return b.String()
+}
+
+func decodeTextRun(raw []byte) string {
+	var unescaped []byte
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '\\' && i+1 < len(raw) {
+			i++
+			if raw[i] == 'r' {
+				unescaped = append(unescaped, '\r')
+				continue
+			}
+		}
+		unescaped = append(unescaped, raw[i])
+	}
+
+	units := make([]uint16, 0, len(unescaped)/2)
+	for i := 0; i+1 < len(unescaped); i += 2 {
+		units = append(units, uint16(unescaped[i])<<8|uint16(unescaped[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+func compareGolden(t *testing.T, name, got string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(want, []byte(got)) {
+		t.Errorf("%s does not match the rendered layout; rerun with -update if the change is intended\n%s", path, firstDifference(string(want), got))
+	}
+}
+
+func firstDifference(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			return fmt.Sprintf("line %d:\n want %q\n  got %q", i+1, w, g)
+		}
+	}
+	return ""
+}
+
+func TestLoadBrandingRejectsUnusableLogo(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	var pngData, jpegData bytes.Buffer
+	if err := png.Encode(&pngData, img); err != nil {
+		t.Fatal(err)
+	}
+	if err := jpeg.Encode(&jpegData, img, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		data    []byte
+		wantErr bool
+	}{
+		{"png", "logo.png", pngData.Bytes(), false},
+		{"jpeg", "logo.jpg", jpegData.Bytes(), false},
+		{"corrupt png", "logo.png", []byte("not an image"), true},
+		{"truncated png", "logo.png", pngData.Bytes()[:20], true},
+		{"jpeg named png", "logo.png", jpegData.Bytes(), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, tt.data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := LoadBranding("", Branding{
+				LegalName:    "Acme Supplies",
+				Email:        "billing@acme.test",
+				LogoPath:     path,
+				PrimaryColor: "#9333EA",
+				TextColor:    "#1F2937",
+				FillColor:    "#F3F4F6",
+			})
+			if tt.wantErr && err == nil {
+				t.Fatal("expected an error")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -2,10 +2,18 @@ package invoice
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/shopspring/decimal"
+	"github.com/tomarrohitt/invoice-go/internal/events"
+	"github.com/tomarrohitt/invoice-go/internal/inbox"
 	"github.com/tomarrohitt/invoice-go/internal/outbox"
 )
 
@@ -14,41 +22,70 @@ type Invoice struct {
 	OrderID   string
 	UserID    string
 	Amount    decimal.Decimal
-	Status    string
+	Currency  string
+	Locale    string
+	Status    Status
 	PDFURL    string
 	CreatedAt time.Time
 	UpdatedAt time.Time
+
+	SourceEvent   json.RawMessage
+	VoidReason    *string
+	VoidedAt      *time.Time
+	Attempts      int
+	FailureReason *string
+	InvoiceNumber *string
+	IssuedAt      *time.Time
+
+	Items   []Item
+	Billing BillingSnapshot
 }
 
+var ErrOrderCancelled = errors.New("order was cancelled before it was invoiced")
+
 type Repository struct {
-	db *pgxpool.Pool
+	db        *pgxpool.Pool
+	numbering NumberingConfig
 }
 
-func NewRepository(db *pgxpool.Pool) *Repository {
-	return &Repository{db: db}
+func NewRepository(db *pgxpool.Pool, numbering NumberingConfig) *Repository {
+	return &Repository{
+		db:        db,
+		numbering: numbering,
+	}
 }
 
-func (r *Repository) Create(ctx context.Context, inv Invoice) error {
-	query := `
-		INSERT INTO invoices (id, order_id, user_id, amount, status, pdf_url)
-		VALUES ($1, $2, $3, $4, $5, $6)
-	`
+func (r *Repository) transition(ctx context.Context, tx pgx.Tx, invoiceID string, to Status, actor, reason string) (Status, error) {
+	var from Status
+	err := tx.QueryRow(ctx, `SELECT status FROM invoices WHERE id = $1 FOR UPDATE`, invoiceID).Scan(&from)
+	if err != nil {
+		return "", err
+	}
 
-	_, err := r.db.Exec(ctx, query,
-		inv.ID,
-		inv.OrderID,
-		inv.UserID,
-		inv.Amount,
-		inv.Status,
-		inv.PDFURL,
-	)
+	if err := checkTransition(from, to); err != nil {
+		return from, err
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE invoices SET status = $2 WHERE id = $1`, invoiceID, to); err != nil {
+		return from, err
+	}
+
+	return from, recordStatusChange(ctx, tx, invoiceID, &from, to, actor, reason)
+}
 
+func recordStatusChange(ctx context.Context, tx pgx.Tx, invoiceID string, from *Status, to Status, actor, reason string) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO invoice_status_history (invoice_id, from_status, to_status, actor, reason)
+		VALUES ($1, $2, $3, $4, $5)
+	`, invoiceID, from, to, actor, reason)
 	return err
 }
 
 func (r *Repository) GetInvoiceByOrderID(ctx context.Context, orderID string) (*Invoice, error) {
 	query := `
-		SELECT id, order_id, user_id, amount, status, pdf_url, created_at, updated_at
+		SELECT id, order_id, user_id, amount, currency, locale, status, pdf_url, created_at, updated_at,
+			source_event, void_reason, voided_at, attempts, failure_reason,
+			invoice_number, issued_at
 		FROM invoices
 		WHERE order_id = $1
 	`
@@ -62,10 +99,19 @@ func (r *Repository) GetInvoiceByOrderID(ctx context.Context, orderID string) (*
 		&inv.OrderID,
 		&inv.UserID,
 		&inv.Amount,
+		&inv.Currency,
+		&inv.Locale,
 		&inv.Status,
 		&inv.PDFURL,
 		&inv.CreatedAt,
 		&inv.UpdatedAt,
+		&inv.SourceEvent,
+		&inv.VoidReason,
+		&inv.VoidedAt,
+		&inv.Attempts,
+		&inv.FailureReason,
+		&inv.InvoiceNumber,
+		&inv.IssuedAt,
 	)
 
 	if err != nil {
@@ -75,47 +121,423 @@ func (r *Repository) GetInvoiceByOrderID(ctx context.Context, orderID string) (*
 	return &inv, nil
 }
 
-func (r *Repository) CreateWithEvent(ctx context.Context, inv Invoice, outboxRepo *outbox.Repository) error {
+func (r *Repository) BeginAttempt(ctx context.Context, inv Invoice) (*Invoice, bool, error) {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		return err
+		return nil, false, err
 	}
 	defer tx.Rollback(ctx)
 
+	if err := lockOrder(ctx, tx, inv.OrderID); err != nil {
+		return nil, false, err
+	}
+
+	var cancelled bool
+	err = tx.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM order_cancellations WHERE order_id = $1)`,
+		inv.OrderID,
+	).Scan(&cancelled)
+	if err != nil {
+		return nil, false, err
+	}
+	if cancelled {
+		return nil, false, ErrOrderCancelled
+	}
+
+	inv.Status = StatusPending
+	inv.Attempts = 1
+
 	query := `
-		INSERT INTO invoices (id, order_id, user_id, amount, status, pdf_url)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO invoices (id, order_id, user_id, amount, currency, locale, status, attempts)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (order_id) DO NOTHING
 	`
 
-	_, err = tx.Exec(ctx, query,
+	inv.Currency = normalizeCurrency(inv.Currency)
+	inv.Locale = resolveLocale(inv.Locale, "")
+	tag, err := tx.Exec(ctx, query, inv.ID, inv.OrderID, inv.UserID, inv.Amount, inv.Currency, inv.Locale, inv.Status, inv.Attempts)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if tag.RowsAffected() == 1 {
+		if err := recordStatusChange(ctx, tx, inv.ID, nil, StatusPending, ActorSystem, "Invoice generation started"); err != nil {
+			return nil, false, err
+		}
+		return &inv, true, tx.Commit(ctx)
+	}
+
+	var existing Invoice
+	err = tx.QueryRow(ctx, `
+		SELECT id, order_id, user_id, amount, currency, locale, status, attempts, created_at
+		FROM invoices
+		WHERE order_id = $1
+		FOR UPDATE
+	`, inv.OrderID).Scan(
+		&existing.ID,
+		&existing.OrderID,
+		&existing.UserID,
+		&existing.Amount,
+		&existing.Currency,
+		&existing.Locale,
+		&existing.Status,
+		&existing.Attempts,
+		&existing.CreatedAt,
+	)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch existing.Status {
+	case StatusPending:
+	case StatusFailed:
+		if _, err := r.transition(ctx, tx, existing.ID, StatusPending, ActorSystem, "Retrying invoice generation"); err != nil {
+			return nil, false, err
+		}
+		existing.Status = StatusPending
+	default:
+		return &existing, false, nil
+	}
+
+	err = tx.QueryRow(ctx,
+		`UPDATE invoices SET attempts = attempts + 1, amount = $2, currency = $3, locale = $4 WHERE id = $1 RETURNING attempts`,
+		existing.ID, inv.Amount, inv.Currency, inv.Locale,
+	).Scan(&existing.Attempts)
+	if err != nil {
+		return nil, false, err
+	}
+	existing.Amount = inv.Amount
+	existing.Currency = inv.Currency
+	existing.Locale = inv.Locale
+
+	return &existing, true, tx.Commit(ctx)
+}
+
+func (r *Repository) RecordCancellation(ctx context.Context, orderID, reason string) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockOrder(ctx, tx, orderID); err != nil {
+		return false, err
+	}
+
+	var invoiced bool
+	err = tx.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM invoices WHERE order_id = $1)`,
+		orderID,
+	).Scan(&invoiced)
+	if err != nil {
+		return false, err
+	}
+	if invoiced {
+		return false, nil
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO order_cancellations (order_id, reason)
+		VALUES ($1, $2)
+		ON CONFLICT (order_id) DO NOTHING
+	`, orderID, reason)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit(ctx)
+}
+
+func lockOrder(ctx context.Context, tx pgx.Tx, orderID string) error {
+	_, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('order:' || $1))`, orderID)
+	return err
+}
+
+func (r *Repository) MarkFailedWithEvent(ctx context.Context, inv Invoice, cause error, outboxRepo *outbox.Repository) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := r.transition(ctx, tx, inv.ID, StatusFailed, ActorSystem, cause.Error()); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE invoices SET failure_reason = $2, failed_at = NOW() WHERE id = $1`,
+		inv.ID, cause.Error(),
+	)
+	if err != nil {
+		return err
+	}
+
+	eventPayload := map[string]any{
+		"invoiceId": inv.ID,
+		"orderId":   inv.OrderID,
+		"userId":    inv.UserID,
+		"error":     cause.Error(),
+		"attempts":  inv.Attempts,
+	}
+
+	err = outboxRepo.InsertCorrelatedEvent(
+		ctx,
+		tx,
 		inv.ID,
 		inv.OrderID,
-		inv.UserID,
-		inv.Amount,
-		inv.Status,
-		inv.PDFURL,
+		"invoice.failed",
+		eventPayload,
 	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *Repository) CompleteWithEvent(ctx context.Context, inv Invoice, outboxRepo *outbox.Repository, inboxRepo *inbox.Repository, messageID string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := r.transition(ctx, tx, inv.ID, StatusGenerated, ActorSystem, "Invoice generated"); err != nil {
+		return err
+	}
 
+	_, err = tx.Exec(ctx, `
+		UPDATE invoices
+		SET pdf_url = $2, source_event = $3, failure_reason = NULL, failed_at = NULL
+		WHERE id = $1
+	`, inv.ID, inv.PDFURL, inv.SourceEvent)
 	if err != nil {
 		return err
 	}
 
+	if err := r.saveSnapshot(ctx, tx, inv.ID, inv.Items, inv.Billing); err != nil {
+		return err
+	}
+
 	eventPayload := map[string]any{
+		"invoiceUrl":    inv.PDFURL,
+		"invoiceNumber": inv.DisplayNumber(),
+		"orderId":       inv.OrderID,
+	}
+
+	err = outboxRepo.InsertCorrelatedEvent(
+		ctx,
+		tx,
+		inv.ID,
+		inv.OrderID,
+		"invoice.generated",
+		eventPayload,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	if err := inboxRepo.Complete(ctx, tx, messageID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *Repository) VoidWithEvent(ctx context.Context, inv Invoice, reason string, outboxRepo *outbox.Repository, inboxRepo *inbox.Repository, messageID string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := r.transition(ctx, tx, inv.ID, StatusVoid, ActorSystem, reason); err != nil {
+		return err
+	}
+
+	var voidedAt time.Time
+	err = tx.QueryRow(ctx, `
+		UPDATE invoices
+		SET void_reason = $2, voided_at = NOW(), pdf_url = $3
+		WHERE id = $1
+		RETURNING voided_at
+	`, inv.ID, reason, inv.PDFURL).Scan(&voidedAt)
+	if err != nil {
+		return err
+	}
+
+	eventPayload := map[string]any{
+		"invoiceId":  inv.ID,
 		"invoiceUrl": inv.PDFURL,
 		"orderId":    inv.OrderID,
+		"reason":     reason,
+		"voidedAt":   voidedAt,
 	}
 
-	err = outboxRepo.InsertEvent(
+	err = outboxRepo.InsertCorrelatedEvent(
 		ctx,
 		tx,
 		inv.ID,
-		"invoice.generated",
+		inv.OrderID,
+		"invoice.voided",
 		eventPayload,
 	)
+	if err != nil {
+		return err
+	}
+
+	if err := inboxRepo.Complete(ctx, tx, messageID); err != nil {
+		return err
+	}
 
+	return tx.Commit(ctx)
+}
+
+func (r *Repository) Quarantine(ctx context.Context, messageID, eventType string, payload []byte, errs ValidationErrors) error {
+	var id *string
+	if messageID != "" {
+		id = &messageID
+	}
+
+	// A redelivery of the same payload is recorded once, but a different
+	// payload under the same message ID is kept alongside the first.
+	hash := sha256.Sum256(payload)
+
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO quarantined_events (message_id, event_type, payload, payload_hash, errors)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (message_id, payload_hash) DO NOTHING
+	`, id, eventType, json.RawMessage(payload), hex.EncodeToString(hash[:]), errs)
+	return err
+}
+
+var (
+	ErrNotCreditable        = errors.New("invoice cannot be credited")
+	ErrCreditExceedsBalance = errors.New("credit exceeds remaining invoice balance")
+)
+
+type CreditNote struct {
+	ID        string
+	Number    string
+	IssuedAt  time.Time
+	InvoiceID string
+	OrderID   string
+	RefundID  string
+	UserID    string
+	Amount    decimal.Decimal
+	Currency  string
+	Reason    string
+	Items     []events.OrderItem
+	PDFURL    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (r *Repository) GetCreditedAmount(ctx context.Context, invoiceID string) (decimal.Decimal, error) {
+	var total decimal.Decimal
+	err := r.db.QueryRow(ctx,
+		`SELECT COALESCE(SUM(amount), 0) FROM credit_notes WHERE invoice_id = $1`,
+		invoiceID,
+	).Scan(&total)
+	return total, err
+}
+
+func (r *Repository) CreateCreditNoteWithEvent(ctx context.Context, cn CreditNote, outboxRepo *outbox.Repository, inboxRepo *inbox.Repository, messageID string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var invoiceAmount decimal.Decimal
+	var status Status
+	err = tx.QueryRow(ctx,
+		`SELECT amount, status FROM invoices WHERE id = $1 FOR UPDATE`,
+		cn.InvoiceID,
+	).Scan(&invoiceAmount, &status)
+	if err != nil {
+		return err
+	}
+	if status != StatusGenerated {
+		return fmt.Errorf("%w: invoice %s is %s", ErrNotCreditable, cn.InvoiceID, status)
+	}
+
+	var credited decimal.Decimal
+	err = tx.QueryRow(ctx,
+		`SELECT COALESCE(SUM(amount), 0) FROM credit_notes WHERE invoice_id = $1`,
+		cn.InvoiceID,
+	).Scan(&credited)
+	if err != nil {
+		return err
+	}
+
+	currency := currencyFor(cn.Currency)
+	remaining := invoiceAmount.Sub(credited)
+	if cn.Amount.GreaterThan(remaining) {
+		return fmt.Errorf("%w: refund %s amount %s, remaining %s", ErrCreditExceedsBalance, cn.RefundID, currency.String(cn.Amount), currency.String(remaining))
+	}
+
+	items, err := json.Marshal(cn.Items)
 	if err != nil {
 		return err
 	}
 
+	query := `
+		INSERT INTO credit_notes (id, credit_note_number, issued_at, invoice_id, order_id, refund_id, user_id, amount, currency, reason, items, pdf_url)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+
+	_, err = tx.Exec(ctx, query,
+		cn.ID,
+		cn.Number,
+		cn.IssuedAt,
+		cn.InvoiceID,
+		cn.OrderID,
+		cn.RefundID,
+		cn.UserID,
+		cn.Amount,
+		normalizeCurrency(cn.Currency),
+		cn.Reason,
+		items,
+		cn.PDFURL,
+	)
+	if err != nil {
+		return err
+	}
+
+	if credited.Add(cn.Amount).GreaterThanOrEqual(invoiceAmount) {
+		if _, err := r.transition(ctx, tx, cn.InvoiceID, StatusCredited, ActorSystem, "Fully credited by refund "+cn.RefundID); err != nil {
+			return err
+		}
+	}
+
+	eventPayload := map[string]any{
+		"creditNoteId":     cn.ID,
+		"creditNoteNumber": cn.Number,
+		"creditNoteUrl":    cn.PDFURL,
+		"invoiceId":        cn.InvoiceID,
+		"orderId":          cn.OrderID,
+		"refundId":         cn.RefundID,
+		"amount":           currency.String(cn.Amount),
+		"currency":         normalizeCurrency(cn.Currency),
+	}
+
+	err = outboxRepo.InsertCorrelatedEvent(
+		ctx,
+		tx,
+		cn.InvoiceID,
+		cn.OrderID,
+		"creditnote.generated",
+		eventPayload,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := inboxRepo.Complete(ctx, tx, messageID); err != nil {
+		return err
+	}
+
 	return tx.Commit(ctx)
 }
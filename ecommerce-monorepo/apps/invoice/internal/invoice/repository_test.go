@@ -0,0 +1,297 @@
+package invoice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/tomarrohitt/invoice-go/internal/inbox"
+	"github.com/tomarrohitt/invoice-go/internal/outbox"
+	"github.com/tomarrohitt/invoice-go/internal/testdb"
+)
+
+// generatedInvoice takes inv through the same PENDING to GENERATED transition
+// the consumer uses.
+func generatedInvoice(t *testing.T, repo *Repository, inv Invoice) *Invoice {
+	t.Helper()
+	ctx := context.Background()
+
+	pending, ready, err := repo.BeginAttempt(ctx, inv)
+	if err != nil || !ready {
+		t.Fatalf("BeginAttempt: ready=%v err=%v", ready, err)
+	}
+
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback(ctx)
+	if _, err := repo.transition(ctx, tx, pending.ID, StatusGenerated, ActorSystem, "Invoice generated"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	pending.Status = StatusGenerated
+	return pending
+}
+
+func TestConcurrentCreditNotesCannotOverCredit(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+
+	repo := NewRepository(db, NumberingConfig{Prefix: "INV", CreditNotePrefix: "CN", FiscalYearStartMonth: 1})
+	outboxRepo := outbox.NewRepository(db)
+	inboxRepo := inbox.NewRepository(db)
+
+	inv := generatedInvoice(t, repo, Invoice{
+		ID:       uuid.New().String(),
+		OrderID:  "order-1",
+		UserID:   "user-1",
+		Amount:   decimal.NewFromInt(100),
+		Currency: "USD",
+	})
+
+	const refunds = 4
+	errs := make([]error, refunds)
+	var wg sync.WaitGroup
+	for i := 0; i < refunds; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = repo.CreateCreditNoteWithEvent(ctx, CreditNote{
+				ID:        uuid.New().String(),
+				InvoiceID: inv.ID,
+				OrderID:   inv.OrderID,
+				RefundID:  fmt.Sprintf("refund-%d", i),
+				UserID:    inv.UserID,
+				Amount:    decimal.NewFromInt(60),
+				Currency:  inv.Currency,
+			}, outboxRepo, inboxRepo, fmt.Sprintf("order.refunded:refund-%d", i))
+		}(i)
+	}
+	wg.Wait()
+
+	var succeeded int
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case errors.Is(err, ErrCreditExceedsBalance):
+		default:
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("%d credit notes issued, want 1", succeeded)
+	}
+
+	credited, err := repo.GetCreditedAmount(ctx, inv.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !credited.Equal(decimal.NewFromInt(60)) {
+		t.Fatalf("credited %s, want 60", credited)
+	}
+}
+
+func TestCreditNoteRejectedOnceFullyCredited(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+
+	repo := NewRepository(db, NumberingConfig{Prefix: "INV", CreditNotePrefix: "CN", FiscalYearStartMonth: 1})
+	outboxRepo := outbox.NewRepository(db)
+	inboxRepo := inbox.NewRepository(db)
+
+	inv := generatedInvoice(t, repo, Invoice{
+		ID:       uuid.New().String(),
+		OrderID:  "order-2",
+		UserID:   "user-1",
+		Amount:   decimal.NewFromInt(100),
+		Currency: "USD",
+	})
+
+	full := CreditNote{
+		ID:        uuid.New().String(),
+		InvoiceID: inv.ID,
+		OrderID:   inv.OrderID,
+		RefundID:  "refund-full",
+		UserID:    inv.UserID,
+		Amount:    decimal.NewFromInt(100),
+		Currency:  inv.Currency,
+	}
+	if err := repo.CreateCreditNoteWithEvent(ctx, full, outboxRepo, inboxRepo, "order.refunded:refund-full"); err != nil {
+		t.Fatal(err)
+	}
+
+	extra := full
+	extra.ID = uuid.New().String()
+	extra.RefundID = "refund-extra"
+	extra.Amount = decimal.NewFromInt(1)
+	err := repo.CreateCreditNoteWithEvent(ctx, extra, outboxRepo, inboxRepo, "order.refunded:refund-extra")
+	if !errors.Is(err, ErrNotCreditable) {
+		t.Fatalf("got %v, want ErrNotCreditable", err)
+	}
+}
+
+func TestCancellationBeforeInvoiceBlocksGeneration(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+
+	repo := NewRepository(db, NumberingConfig{Prefix: "INV", CreditNotePrefix: "CN", FiscalYearStartMonth: 1})
+
+	recorded, err := repo.RecordCancellation(ctx, "order-3", "Customer changed their mind")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !recorded {
+		t.Fatal("cancellation of an uninvoiced order was not recorded")
+	}
+
+	_, ready, err := repo.BeginAttempt(ctx, Invoice{
+		ID:       uuid.New().String(),
+		OrderID:  "order-3",
+		UserID:   "user-1",
+		Amount:   decimal.NewFromInt(100),
+		Currency: "USD",
+	})
+	if ready || !errors.Is(err, ErrOrderCancelled) {
+		t.Fatalf("got ready=%v err=%v, want ErrOrderCancelled", ready, err)
+	}
+
+	if _, err := repo.GetInvoiceByOrderID(ctx, "order-3"); err == nil {
+		t.Fatal("invoice was created for a cancelled order")
+	}
+}
+
+func TestCancellationAfterInvoiceIsNotRecorded(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+
+	repo := NewRepository(db, NumberingConfig{Prefix: "INV", CreditNotePrefix: "CN", FiscalYearStartMonth: 1})
+
+	_, ready, err := repo.BeginAttempt(ctx, Invoice{
+		ID:       uuid.New().String(),
+		OrderID:  "order-4",
+		UserID:   "user-1",
+		Amount:   decimal.NewFromInt(100),
+		Currency: "USD",
+	})
+	if err != nil || !ready {
+		t.Fatalf("BeginAttempt: ready=%v err=%v", ready, err)
+	}
+
+	recorded, err := repo.RecordCancellation(ctx, "order-4", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recorded {
+		t.Fatal("cancellation recorded for an order that already has an invoice")
+	}
+}
+
+func TestReserveNumberIsSequentialAndStable(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+
+	repo := NewRepository(db, NumberingConfig{Prefix: "INV", CreditNotePrefix: "CN", FiscalYearStartMonth: 1})
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		inv, ready, err := repo.BeginAttempt(ctx, Invoice{
+			ID:       uuid.New().String(),
+			OrderID:  fmt.Sprintf("order-n%d", i),
+			UserID:   "user-1",
+			Amount:   decimal.NewFromInt(10),
+			Currency: "USD",
+		})
+		if err != nil || !ready {
+			t.Fatalf("BeginAttempt: ready=%v err=%v", ready, err)
+		}
+		ids = append(ids, inv.ID)
+	}
+
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		number, _, err := repo.ReserveNumber(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[number] = true
+	}
+
+	year := repo.numbering.fiscalYear(time.Now())
+	for seq := int64(1); seq <= 3; seq++ {
+		if want := repo.numbering.format(repo.numbering.Prefix, year, seq); !seen[want] {
+			t.Errorf("number %s was not allocated, got %v", want, seen)
+		}
+	}
+
+	first, firstIssued, err := repo.ReserveNumber(ctx, ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, againIssued, err := repo.ReserveNumber(ctx, ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != again || !firstIssued.Equal(againIssued) {
+		t.Fatalf("retry reserved %s at %s, want %s at %s", again, againIssued, first, firstIssued)
+	}
+}
+
+func TestReserveCreditNoteNumberHasItsOwnSequence(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+
+	repo := NewRepository(db, NumberingConfig{Prefix: "INV", CreditNotePrefix: "CN", FiscalYearStartMonth: 1})
+	year := repo.numbering.fiscalYear(time.Now())
+
+	for i, refundID := range []string{"refund-1", "refund-2", "refund-1"} {
+		number, _, err := repo.ReserveCreditNoteNumber(ctx, refundID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seq := int64(i + 1)
+		if refundID == "refund-1" {
+			seq = 1
+		}
+		if want := repo.numbering.format("CN", year, seq); number != want {
+			t.Errorf("%s: got %s, want %s", refundID, number, want)
+		}
+	}
+}
+
+func TestQuarantineKeepsEachDistinctPayload(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+
+	repo := NewRepository(db, NumberingConfig{Prefix: "INV", CreditNotePrefix: "CN", FiscalYearStartMonth: 1})
+	errs := ValidationErrors{{Field: "data.totalAmount", Message: "must be positive"}}
+
+	payloads := []string{
+		`{"data":{"orderId":"order-q","totalAmount":0}}`,
+		`{"data":{"orderId":"order-q","totalAmount":-1}}`,
+		`{"data":{"orderId":"order-q","totalAmount":0}}`,
+	}
+	for _, payload := range payloads {
+		if err := repo.Quarantine(ctx, "order.paid:order-q", "order.paid", []byte(payload), errs); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var count int
+	err := db.QueryRow(ctx, `SELECT COUNT(*) FROM quarantined_events WHERE message_id = $1`, "order.paid:order-q").Scan(&count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Fatalf("%d quarantined rows, want one per distinct payload (2)", count)
+	}
+}
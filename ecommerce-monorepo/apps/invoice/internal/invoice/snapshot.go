@@ -0,0 +1,231 @@
+package invoice
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+	"github.com/tomarrohitt/invoice-go/internal/events"
+)
+
+type Item struct {
+	Position  int
+	ProductID string
+	Name      string
+	UnitPrice decimal.Decimal
+	Quantity  int
+	LineTotal decimal.Decimal
+
+	HSNCode      string
+	TaxRate      decimal.Decimal
+	TaxableValue decimal.Decimal
+	CGST         decimal.Decimal
+	SGST         decimal.Decimal
+	IGST         decimal.Decimal
+}
+
+type BillingSnapshot struct {
+	CustomerName    string
+	CustomerEmail   string
+	ShippingAddress events.Address
+	BillingAddress  events.Address
+	Subtotal        decimal.Decimal
+	TaxAmount       decimal.Decimal
+	PaymentID       string
+	Currency        string
+	SellerGSTIN     string
+	BuyerGSTIN      string
+	PlaceOfSupply   string
+}
+
+func snapshotFromEvent(event events.OrderPaidEvent, gst *GSTBreakdown) ([]Item, BillingSnapshot) {
+	currency := currencyFor(event.Data.Currency)
+
+	items := make([]Item, 0, len(event.Data.Items))
+	for i, item := range event.Data.Items {
+		snapshot := Item{
+			Position:     i + 1,
+			ProductID:    item.ProductID,
+			Name:         item.Name,
+			UnitPrice:    currency.Round(item.Price),
+			Quantity:     item.Quantity,
+			LineTotal:    currency.LineTotal(item.Price, item.Quantity),
+			HSNCode:      item.HSNCode,
+			TaxRate:      item.TaxRate,
+			TaxableValue: currency.LineTotal(item.Price, item.Quantity),
+		}
+		if gst != nil {
+			line := gst.Lines[i]
+			snapshot.TaxableValue = line.TaxableValue
+			snapshot.CGST = line.CGST
+			snapshot.SGST = line.SGST
+			snapshot.IGST = line.IGST
+		}
+		items = append(items, snapshot)
+	}
+
+	billing := BillingSnapshot{
+		CustomerName:    event.Data.UserName,
+		CustomerEmail:   event.Data.UserEmail,
+		ShippingAddress: event.Data.ShippingAddress,
+		BillingAddress:  event.Data.BillingAddress,
+		Subtotal:        currency.Round(event.Data.Subtotal),
+		TaxAmount:       currency.Round(event.Data.TaxedAmount),
+		PaymentID:       event.Data.PaymentID,
+		Currency:        currency.Code,
+		BuyerGSTIN:      event.Data.BuyerGSTIN,
+	}
+	if gst != nil {
+		billing.SellerGSTIN = gst.SellerGSTIN
+		billing.PlaceOfSupply = gst.PlaceOfSupply
+	}
+
+	return items, billing
+}
+
+func (b BillingSnapshot) OrderPaidEvent(inv *Invoice, items []Item) events.OrderPaidEvent {
+	var event events.OrderPaidEvent
+	event.EventName = "order.paid"
+	event.Data.OrderID = inv.OrderID
+	event.Data.UserID = inv.UserID
+	event.Data.UserName = b.CustomerName
+	event.Data.UserEmail = b.CustomerEmail
+	event.Data.TotalAmount = inv.Amount
+	event.Data.Subtotal = b.Subtotal
+	event.Data.TaxedAmount = b.TaxAmount
+	event.Data.PaymentID = b.PaymentID
+	event.Data.Currency = b.Currency
+	event.Data.Locale = inv.Locale
+	event.Data.BuyerGSTIN = b.BuyerGSTIN
+	event.Data.ShippingAddress = b.ShippingAddress
+	event.Data.BillingAddress = b.BillingAddress
+	event.Data.CreatedAt = inv.CreatedAt
+
+	for _, item := range items {
+		event.Data.Items = append(event.Data.Items, events.OrderItem{
+			ProductID: item.ProductID,
+			Name:      item.Name,
+			Price:     item.UnitPrice,
+			Quantity:  item.Quantity,
+			HSNCode:   item.HSNCode,
+			TaxRate:   item.TaxRate,
+		})
+	}
+
+	return event
+}
+
+func (r *Repository) saveSnapshot(ctx context.Context, tx pgx.Tx, invoiceID string, items []Item, billing BillingSnapshot) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO invoice_billing_snapshots (
+			invoice_id, customer_name, customer_email, shipping_address, billing_address,
+			subtotal, tax_amount, payment_id, currency,
+			seller_gstin, buyer_gstin, place_of_supply
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (invoice_id) DO UPDATE
+		SET customer_name = EXCLUDED.customer_name,
+			customer_email = EXCLUDED.customer_email,
+			shipping_address = EXCLUDED.shipping_address,
+			billing_address = EXCLUDED.billing_address,
+			subtotal = EXCLUDED.subtotal,
+			tax_amount = EXCLUDED.tax_amount,
+			payment_id = EXCLUDED.payment_id,
+			currency = EXCLUDED.currency,
+			seller_gstin = EXCLUDED.seller_gstin,
+			buyer_gstin = EXCLUDED.buyer_gstin,
+			place_of_supply = EXCLUDED.place_of_supply
+	`,
+		invoiceID,
+		billing.CustomerName,
+		billing.CustomerEmail,
+		billing.ShippingAddress,
+		billing.BillingAddress,
+		billing.Subtotal,
+		billing.TaxAmount,
+		billing.PaymentID,
+		billing.Currency,
+		billing.SellerGSTIN,
+		billing.BuyerGSTIN,
+		billing.PlaceOfSupply,
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM invoice_items WHERE invoice_id = $1`, invoiceID); err != nil {
+		return err
+	}
+
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{"invoice_items"},
+		[]string{
+			"invoice_id", "position", "product_id", "name", "unit_price", "quantity", "line_total",
+			"hsn_code", "tax_rate", "taxable_value", "cgst_amount", "sgst_amount", "igst_amount",
+		},
+		pgx.CopyFromSlice(len(items), func(i int) ([]any, error) {
+			item := items[i]
+			return []any{
+				invoiceID, item.Position, item.ProductID, item.Name, item.UnitPrice, item.Quantity, item.LineTotal,
+				item.HSNCode, item.TaxRate, item.TaxableValue, item.CGST, item.SGST, item.IGST,
+			}, nil
+		}),
+	)
+	return err
+}
+
+func (r *Repository) GetItems(ctx context.Context, invoiceID string) ([]Item, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT position, product_id, name, unit_price, quantity, line_total,
+			hsn_code, tax_rate, taxable_value, cgst_amount, sgst_amount, igst_amount
+		FROM invoice_items
+		WHERE invoice_id = $1
+		ORDER BY position
+	`, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []Item
+	for rows.Next() {
+		var item Item
+		err := rows.Scan(
+			&item.Position, &item.ProductID, &item.Name, &item.UnitPrice, &item.Quantity, &item.LineTotal,
+			&item.HSNCode, &item.TaxRate, &item.TaxableValue, &item.CGST, &item.SGST, &item.IGST,
+		)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (r *Repository) GetBillingSnapshot(ctx context.Context, invoiceID string) (*BillingSnapshot, error) {
+	var b BillingSnapshot
+	err := r.db.QueryRow(ctx, `
+		SELECT customer_name, customer_email, shipping_address, billing_address,
+			subtotal, tax_amount, payment_id, currency,
+			seller_gstin, buyer_gstin, place_of_supply
+		FROM invoice_billing_snapshots
+		WHERE invoice_id = $1
+	`, invoiceID).Scan(
+		&b.CustomerName,
+		&b.CustomerEmail,
+		&b.ShippingAddress,
+		&b.BillingAddress,
+		&b.Subtotal,
+		&b.TaxAmount,
+		&b.PaymentID,
+		&b.Currency,
+		&b.SellerGSTIN,
+		&b.BuyerGSTIN,
+		&b.PlaceOfSupply,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
@@ -0,0 +1,44 @@
+package invoice
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "PENDING"
+	StatusGenerated Status = "GENERATED"
+	StatusFailed    Status = "FAILED"
+	StatusVoid      Status = "VOID"
+	StatusCredited  Status = "CREDITED"
+)
+
+const ActorSystem = "system"
+
+var ErrInvalidTransition = errors.New("invalid invoice status transition")
+
+var transitions = map[Status][]Status{
+	StatusPending:   {StatusGenerated, StatusFailed, StatusVoid},
+	StatusFailed:    {StatusPending, StatusGenerated, StatusVoid},
+	StatusGenerated: {StatusVoid, StatusCredited},
+	StatusCredited:  {StatusVoid},
+	StatusVoid:      {},
+}
+
+func (s Status) CanTransitionTo(next Status) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+func checkTransition(from, to Status) error {
+	if !from.CanTransitionTo(to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+	}
+	return nil
+}
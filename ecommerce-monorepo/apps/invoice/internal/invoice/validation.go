@@ -0,0 +1,116 @@
+package invoice
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/tomarrohitt/invoice-go/internal/events"
+)
+
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type ValidationErrors []ValidationError
+
+func (v ValidationErrors) Error() string {
+	msgs := make([]string, len(v))
+	for i, e := range v {
+		msgs[i] = fmt.Sprintf("%s: %s", e.Field, e.Message)
+	}
+	return "invalid event: " + strings.Join(msgs, "; ")
+}
+
+func (v *ValidationErrors) add(field, format string, args ...any) {
+	*v = append(*v, ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func ValidateOrderPaid(event events.OrderPaidEvent) ValidationErrors {
+	var errs ValidationErrors
+	data := event.Data
+
+	if strings.TrimSpace(data.OrderID) == "" {
+		errs.add("data.orderId", "is required")
+	}
+	if strings.TrimSpace(data.UserID) == "" {
+		errs.add("data.userId", "is required")
+	}
+	if len(data.Items) == 0 {
+		errs.add("data.items", "must contain at least one item")
+	}
+
+	currency, ok := lookupCurrency(data.Currency)
+	if !ok {
+		errs.add("data.currency", "unsupported currency %q", data.Currency)
+		currency = currencyFor(data.Currency)
+	}
+
+	itemsTotal := decimal.Zero
+	gstTax := decimal.Zero
+	gstItems := false
+	for i, item := range data.Items {
+		field := fmt.Sprintf("data.items[%d]", i)
+		if strings.TrimSpace(item.Name) == "" {
+			errs.add(field+".name", "is required")
+		}
+		if item.Quantity <= 0 {
+			errs.add(field+".quantity", "must be positive, got %d", item.Quantity)
+		}
+		if item.Price.IsNegative() {
+			errs.add(field+".price", "must not be negative, got %s", item.Price)
+		}
+		if item.HSNCode != "" && !hsnCodePattern.MatchString(item.HSNCode) {
+			errs.add(field+".hsnCode", "%q is not a 4, 6 or 8 digit HSN/SAC code", item.HSNCode)
+		}
+		if item.TaxRate.IsNegative() || item.TaxRate.GreaterThan(hundred) {
+			errs.add(field+".taxRate", "must be between 0 and 100, got %s", item.TaxRate)
+		}
+		lineTotal := currency.LineTotal(item.Price, item.Quantity)
+		itemsTotal = itemsTotal.Add(lineTotal)
+
+		if item.HSNCode != "" || !item.TaxRate.IsZero() {
+			gstItems = true
+		}
+		gstTax = gstTax.Add(currency.Round(lineTotal.Mul(item.TaxRate).Div(hundred)))
+	}
+
+	if data.BuyerGSTIN != "" && !validGSTIN(data.BuyerGSTIN) {
+		errs.add("data.buyerGstin", "%q is not a valid GSTIN", data.BuyerGSTIN)
+	}
+	if hasGSTDetails(event) && isIndia(data.ShippingAddress.Country) {
+		if _, ok := gstStateCode(data.ShippingAddress.State); !ok {
+			errs.add("data.shippingAddress.state", "unknown Indian state %q", data.ShippingAddress.State)
+		}
+	}
+
+	subtotal := currency.Round(data.Subtotal)
+	tax := currency.Round(data.TaxedAmount)
+	total := currency.Round(data.TotalAmount)
+
+	if subtotal.IsNegative() {
+		errs.add("data.subtotal", "must not be negative")
+	}
+	if tax.IsNegative() {
+		errs.add("data.taxedAmount", "must not be negative")
+	}
+	if !total.IsPositive() {
+		errs.add("data.totalAmount", "must be positive")
+	}
+
+	if len(data.Items) > 0 && !itemsTotal.Equal(subtotal) {
+		errs.add("data.subtotal", "items sum to %s but subtotal is %s", currency.String(itemsTotal), currency.String(subtotal))
+	}
+	if gstItems && !gstTax.Equal(tax) {
+		errs.add("data.taxedAmount", "item tax rates sum to %s but taxed amount is %s", currency.String(gstTax), currency.String(tax))
+	}
+	if !subtotal.Add(tax).Equal(total) {
+		errs.add("data.totalAmount", "subtotal %s + tax %s does not equal total %s", currency.String(subtotal), currency.String(tax), currency.String(total))
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
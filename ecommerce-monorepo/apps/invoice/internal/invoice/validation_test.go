@@ -0,0 +1,170 @@
+package invoice
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/tomarrohitt/invoice-go/internal/events"
+)
+
+func validOrderPaid() events.OrderPaidEvent {
+	var event events.OrderPaidEvent
+	event.EventName = "order.paid"
+	event.Data.OrderID = "order-1"
+	event.Data.UserID = "user-1"
+	event.Data.Currency = "USD"
+	event.Data.Items = []events.OrderItem{
+		{ProductID: "p1", Name: "Shirt", Price: decimal.RequireFromString("19.99"), Quantity: 2},
+		{ProductID: "p2", Name: "Socks", Price: decimal.RequireFromString("5.00"), Quantity: 1},
+	}
+	event.Data.Subtotal = decimal.RequireFromString("44.98")
+	event.Data.TaxedAmount = decimal.RequireFromString("4.50")
+	event.Data.TotalAmount = decimal.RequireFromString("49.48")
+	return event
+}
+
+func validGSTOrderPaid() events.OrderPaidEvent {
+	event := validOrderPaid()
+	event.Data.Currency = "INR"
+	event.Data.BuyerGSTIN = "29AAACR5055K1Z5"
+	event.Data.ShippingAddress = events.Address{State: "Karnataka", Country: "IN"}
+	event.Data.Items = []events.OrderItem{
+		{Name: "Shirt", Price: decimal.NewFromInt(500), Quantity: 2, HSNCode: "6205", TaxRate: decimal.NewFromInt(5)},
+		{Name: "Repair", Price: decimal.NewFromInt(500), Quantity: 1, HSNCode: "998719", TaxRate: decimal.NewFromInt(18)},
+	}
+	event.Data.Subtotal = decimal.NewFromInt(1500)
+	event.Data.TaxedAmount = decimal.NewFromInt(140)
+	event.Data.TotalAmount = decimal.NewFromInt(1640)
+	return event
+}
+
+func TestValidateOrderPaid(t *testing.T) {
+	tests := []struct {
+		name   string
+		event  func() events.OrderPaidEvent
+		mutate func(*events.OrderPaidEvent)
+		fields []string
+	}{
+		{
+			name:   "valid",
+			event:  validOrderPaid,
+			mutate: func(e *events.OrderPaidEvent) {},
+		},
+		{
+			name:  "items do not sum to subtotal",
+			event: validOrderPaid,
+			mutate: func(e *events.OrderPaidEvent) {
+				e.Data.Subtotal = decimal.RequireFromString("40.00")
+				e.Data.TotalAmount = decimal.RequireFromString("44.50")
+			},
+			fields: []string{"data.subtotal"},
+		},
+		{
+			name:  "subtotal plus tax does not equal total",
+			event: validOrderPaid,
+			mutate: func(e *events.OrderPaidEvent) {
+				e.Data.TotalAmount = decimal.RequireFromString("50.00")
+			},
+			fields: []string{"data.totalAmount"},
+		},
+		{
+			name:  "negative quantity",
+			event: validOrderPaid,
+			mutate: func(e *events.OrderPaidEvent) {
+				e.Data.Items[1].Quantity = -1
+				e.Data.Subtotal = decimal.RequireFromString("34.98")
+				e.Data.TotalAmount = decimal.RequireFromString("39.48")
+			},
+			fields: []string{"data.items[1].quantity"},
+		},
+		{
+			name:  "missing user id",
+			event: validOrderPaid,
+			mutate: func(e *events.OrderPaidEvent) {
+				e.Data.UserID = " "
+			},
+			fields: []string{"data.userId"},
+		},
+		{
+			name:  "unsupported currency",
+			event: validOrderPaid,
+			mutate: func(e *events.OrderPaidEvent) {
+				e.Data.Currency = "XYZ"
+			},
+			fields: []string{"data.currency"},
+		},
+		{
+			name:   "valid gst",
+			event:  validGSTOrderPaid,
+			mutate: func(e *events.OrderPaidEvent) {},
+		},
+		{
+			name:  "malformed hsn code",
+			event: validGSTOrderPaid,
+			mutate: func(e *events.OrderPaidEvent) {
+				e.Data.Items[0].HSNCode = "62A5"
+			},
+			fields: []string{"data.items[0].hsnCode"},
+		},
+		{
+			name:  "tax rate out of range",
+			event: validGSTOrderPaid,
+			mutate: func(e *events.OrderPaidEvent) {
+				e.Data.Items[1].TaxRate = decimal.NewFromInt(-18)
+				e.Data.TaxedAmount = decimal.NewFromInt(-40)
+				e.Data.TotalAmount = decimal.NewFromInt(1460)
+			},
+			fields: []string{"data.items[1].taxRate", "data.taxedAmount"},
+		},
+		{
+			name:  "invalid buyer gstin",
+			event: validGSTOrderPaid,
+			mutate: func(e *events.OrderPaidEvent) {
+				e.Data.BuyerGSTIN = "29AAACR5055K1X5"
+			},
+			fields: []string{"data.buyerGstin"},
+		},
+		{
+			name:  "unknown indian state",
+			event: validGSTOrderPaid,
+			mutate: func(e *events.OrderPaidEvent) {
+				e.Data.ShippingAddress.State = "TC"
+			},
+			fields: []string{"data.shippingAddress.state"},
+		},
+		{
+			name:  "item tax does not match taxed amount",
+			event: validGSTOrderPaid,
+			mutate: func(e *events.OrderPaidEvent) {
+				e.Data.TaxedAmount = decimal.NewFromInt(150)
+				e.Data.TotalAmount = decimal.NewFromInt(1650)
+			},
+			fields: []string{"data.taxedAmount"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := tt.event()
+			tt.mutate(&event)
+
+			errs := ValidateOrderPaid(event)
+
+			got := make(map[string]bool)
+			for _, e := range errs {
+				got[e.Field] = true
+			}
+			for _, field := range tt.fields {
+				if !got[field] {
+					t.Errorf("expected an error on %s, got %v", field, errs)
+				}
+			}
+			if len(tt.fields) == 0 && errs != nil {
+				t.Errorf("expected no errors, got %v", errs)
+			}
+			if len(errs) > len(tt.fields) {
+				t.Errorf("unexpected extra errors: %v", errs)
+			}
+		})
+	}
+}
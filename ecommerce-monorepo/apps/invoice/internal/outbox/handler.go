@@ -0,0 +1,164 @@
+package outbox
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+var validStatuses = map[string]bool{
+	"PENDING":    true,
+	"PROCESSING": true,
+	"PROCESSED":  true,
+	"FAILED":     true,
+}
+
+type Handler struct {
+	repo *Repository
+}
+
+func NewHandler(repo *Repository) *Handler {
+	return &Handler{repo: repo}
+}
+
+func (h *Handler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	filter := ListFilter{
+		Status:      q.Get("status"),
+		EventType:   q.Get("eventType"),
+		AggregateID: q.Get("aggregateId"),
+		Limit:       defaultListLimit,
+	}
+
+	if filter.Status != "" && !validStatuses[filter.Status] {
+		http.Error(w, "invalid status", http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	if filter.From, err = parseTimeParam(q.Get("from")); err != nil {
+		http.Error(w, "from must be RFC3339", http.StatusBadRequest)
+		return
+	}
+	if filter.To, err = parseTimeParam(q.Get("to")); err != nil {
+		http.Error(w, "to must be RFC3339", http.StatusBadRequest)
+		return
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > maxListLimit {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = limit
+	}
+	if v := q.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			http.Error(w, "invalid offset", http.StatusBadRequest)
+			return
+		}
+		filter.Offset = offset
+	}
+
+	events, err := h.repo.ListEvents(r.Context(), filter)
+	if err != nil {
+		log.Printf("[Outbox Admin] List failed: %v", err)
+		http.Error(w, "Failed to list outbox events", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"events": events,
+		"limit":  filter.Limit,
+		"offset": filter.Offset,
+	})
+}
+
+func (h *Handler) GetEvent(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, err := uuid.Parse(id); err != nil {
+		http.Error(w, "invalid event id", http.StatusBadRequest)
+		return
+	}
+
+	event, err := h.repo.GetEvent(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("[Outbox Admin] Get %s failed: %v", id, err)
+		http.Error(w, "Failed to load outbox event", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, event)
+}
+
+func (h *Handler) RequeueEvent(w http.ResponseWriter, r *http.Request) {
+	h.requeue(w, r, []string{r.PathValue("id")})
+}
+
+func (h *Handler) RequeueEvents(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		IDs []string `json:"ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || len(body.IDs) == 0 {
+		http.Error(w, "ids required", http.StatusBadRequest)
+		return
+	}
+
+	h.requeue(w, r, body.IDs)
+}
+
+func (h *Handler) requeue(w http.ResponseWriter, r *http.Request, ids []string) {
+	for _, id := range ids {
+		if _, err := uuid.Parse(id); err != nil {
+			http.Error(w, "invalid event id: "+id, http.StatusBadRequest)
+			return
+		}
+	}
+
+	n, err := h.repo.RequeueEvents(r.Context(), ids)
+	if err != nil {
+		log.Printf("[Outbox Admin] Requeue failed: %v", err)
+		http.Error(w, "Failed to requeue outbox events", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("[Outbox Admin] Requeued %d of %d events", n, len(ids))
+	writeJSON(w, http.StatusOK, map[string]any{
+		"requeued": n,
+	})
+}
+
+func parseTimeParam(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
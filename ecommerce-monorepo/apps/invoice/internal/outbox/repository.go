@@ -3,18 +3,59 @@ package outbox
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-type Repository struct{}
+const (
+	NotifyChannel        = "outbox_events"
+	CurrentSchemaVersion = 1
+)
+
+type Event struct {
+	ID            string          `json:"id"`
+	AggregateID   string          `json:"aggregateId"`
+	CorrelationID *string         `json:"correlationId"`
+	EventType     string          `json:"eventType"`
+	SchemaVersion int             `json:"schemaVersion"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Retries       int             `json:"retries"`
+	Error         *string         `json:"error"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	UpdatedAt     time.Time       `json:"updatedAt"`
+	PublishedAt   *time.Time      `json:"publishedAt"`
+	NextAttemptAt time.Time       `json:"nextAttemptAt"`
+}
+
+type ListFilter struct {
+	Status      string
+	EventType   string
+	AggregateID string
+	From        *time.Time
+	To          *time.Time
+	Limit       int
+	Offset      int
+}
+
+type Repository struct {
+	db *pgxpool.Pool
+}
 
-func NewRepository() *Repository {
-	return &Repository{}
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{db: db}
 }
 
 func (r *Repository) InsertEvent(ctx context.Context, tx pgx.Tx, aggregateID string, eventType string, payload any) error {
+	return r.InsertCorrelatedEvent(ctx, tx, aggregateID, aggregateID, eventType, payload)
+}
+
+func (r *Repository) InsertCorrelatedEvent(ctx context.Context, tx pgx.Tx, aggregateID, correlationID, eventType string, payload any) error {
 	data, err := json.Marshal(payload)
 	if err != nil {
 		return err
@@ -25,13 +66,129 @@ func (r *Repository) InsertEvent(ctx context.Context, tx pgx.Tx, aggregateID str
 		INSERT INTO outbox_events (
 			id,
 			aggregate_id,
+			correlation_id,
 			event_type,
+			schema_version,
 			payload,
 			status
 		)
-		VALUES ($1, $2, $3, $4, 'PENDING')
+		VALUES ($1, $2, $3, $4, $5, $6, 'PENDING')
 	`
 
-	_, err = tx.Exec(ctx, query, id, aggregateID, eventType, data)
+	if _, err = tx.Exec(ctx, query, id, aggregateID, correlationID, eventType, CurrentSchemaVersion, data); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `SELECT pg_notify($1, $2)`, NotifyChannel, id)
 	return err
 }
+
+const eventColumns = `id, aggregate_id, correlation_id, event_type, schema_version, payload, status, retries, error, created_at, updated_at, published_at, next_attempt_at`
+
+func scanEvent(row pgx.Row) (*Event, error) {
+	var e Event
+	err := row.Scan(
+		&e.ID,
+		&e.AggregateID,
+		&e.CorrelationID,
+		&e.EventType,
+		&e.SchemaVersion,
+		&e.Payload,
+		&e.Status,
+		&e.Retries,
+		&e.Error,
+		&e.CreatedAt,
+		&e.UpdatedAt,
+		&e.PublishedAt,
+		&e.NextAttemptAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *Repository) ListEvents(ctx context.Context, f ListFilter) ([]Event, error) {
+	var conditions []string
+	var args []any
+
+	add := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if f.Status != "" {
+		add("status = $%d", f.Status)
+	}
+	if f.EventType != "" {
+		add("event_type = $%d", f.EventType)
+	}
+	if f.AggregateID != "" {
+		add("aggregate_id = $%d", f.AggregateID)
+	}
+	if f.From != nil {
+		add("created_at >= $%d", *f.From)
+	}
+	if f.To != nil {
+		add("created_at < $%d", *f.To)
+	}
+
+	query := `SELECT ` + eventColumns + ` FROM outbox_events`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, f.Limit, f.Offset)
+	query += fmt.Sprintf(` ORDER BY created_at DESC LIMIT $%d OFFSET $%d`, len(args)-1, len(args))
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []Event{}
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *e)
+	}
+
+	return events, rows.Err()
+}
+
+func (r *Repository) GetEvent(ctx context.Context, id string) (*Event, error) {
+	query := `SELECT ` + eventColumns + ` FROM outbox_events WHERE id = $1`
+	return scanEvent(r.db.QueryRow(ctx, query, id))
+}
+
+func (r *Repository) RequeueEvents(ctx context.Context, ids []string) (int64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE outbox_events
+		SET status = 'PENDING',
+			retries = 0,
+			next_attempt_at = NOW(),
+			locked_by = NULL,
+			locked_until = NULL
+		WHERE id = ANY($1::uuid[]) AND status <> 'PROCESSING'
+	`, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	if tag.RowsAffected() > 0 {
+		if _, err := tx.Exec(ctx, `SELECT pg_notify($1, 'requeue')`, NotifyChannel); err != nil {
+			return 0, err
+		}
+	}
+
+	return tag.RowsAffected(), tx.Commit(ctx)
+}
@@ -0,0 +1,116 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RetentionConfig struct {
+	MaxAge    time.Duration
+	Interval  time.Duration
+	BatchSize int
+	Archive   bool
+}
+
+type RetentionJob struct {
+	db        *pgxpool.Pool
+	maxAge    time.Duration
+	interval  time.Duration
+	batchSize int
+	archive   bool
+}
+
+func NewRetentionJob(db *pgxpool.Pool, cfg RetentionConfig) *RetentionJob {
+	return &RetentionJob{
+		db:        db,
+		maxAge:    cfg.MaxAge,
+		interval:  cfg.Interval,
+		batchSize: cfg.BatchSize,
+		archive:   cfg.Archive,
+	}
+}
+
+func (j *RetentionJob) Start(ctx context.Context) {
+	go j.loop(ctx)
+}
+
+func (j *RetentionJob) loop(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Outbox retention job stopped")
+			return
+		case <-ticker.C:
+			j.run(ctx)
+		}
+	}
+}
+
+func (j *RetentionJob) run(ctx context.Context) {
+	start := time.Now()
+	var total int64
+
+	for ctx.Err() == nil {
+		n, err := j.purgeBatch(ctx)
+		if err != nil {
+			log.Println("Outbox retention error:", err)
+			break
+		}
+		total += n
+		if n < int64(j.batchSize) {
+			break
+		}
+	}
+
+	action := "deleted"
+	if j.archive {
+		action = "archived"
+	}
+	log.Printf("[Outbox] Retention %s %d processed events older than %s in %s", action, total, j.maxAge, time.Since(start).Round(time.Millisecond))
+}
+
+func (j *RetentionJob) purgeBatch(ctx context.Context) (int64, error) {
+	query := `
+		DELETE FROM outbox_events
+		WHERE id IN (
+			SELECT id
+			FROM outbox_events
+			WHERE status = 'PROCESSED' AND published_at < NOW() - make_interval(secs => $1)
+			ORDER BY published_at ASC
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+	`
+
+	if j.archive {
+		query = `
+			WITH moved AS (
+				DELETE FROM outbox_events
+				WHERE id IN (
+					SELECT id
+					FROM outbox_events
+					WHERE status = 'PROCESSED' AND published_at < NOW() - make_interval(secs => $1)
+					ORDER BY published_at ASC
+					LIMIT $2
+					FOR UPDATE SKIP LOCKED
+				)
+				RETURNING id, aggregate_id, correlation_id, event_type, schema_version, payload, created_at, published_at, retries, error
+			)
+			INSERT INTO outbox_events_archive (id, aggregate_id, correlation_id, event_type, schema_version, payload, created_at, published_at, retries, error)
+			SELECT id, aggregate_id, correlation_id, event_type, schema_version, payload, created_at, published_at, retries, error
+			FROM moved
+		`
+	}
+
+	tag, err := j.db.Exec(ctx, query, j.maxAge.Seconds(), j.batchSize)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
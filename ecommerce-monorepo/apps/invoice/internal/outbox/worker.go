@@ -4,32 +4,76 @@ import (
 	"context"
 	"encoding/json"
 	"log"
+	"sort"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tomarrohitt/invoice-go/internal/eventbus"
 )
 
+type WorkerConfig struct {
+	PollInterval   time.Duration
+	BatchSize      int
+	Concurrency    int
+	MaxRetries     int
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+}
+
 type Worker struct {
-	db        *pgxpool.Pool
-	eventBus  Publisher
-	interval  time.Duration
-	batchSize int
+	db          *pgxpool.Pool
+	eventBus    Publisher
+	id          string
+	interval    time.Duration
+	batchSize   int
+	concurrency int
+	lease       time.Duration
+
+	maxRetries     int
+	retryBaseDelay time.Duration
+	retryMaxDelay  time.Duration
+
+	wake chan struct{}
 }
 
 type Publisher interface {
-	Publish(context.Context, string, any) error
+	PublishWithMetadata(context.Context, string, any, eventbus.Metadata) error
 }
 
-func NewWorker(db *pgxpool.Pool, bus Publisher) *Worker {
+type claimedEvent struct {
+	id            string
+	aggregateID   string
+	correlationID *string
+	eventType     string
+	schemaVersion int
+	payload       []byte
+	retries       int
+	seq           int64
+	createdAt     time.Time
+}
+
+func NewWorker(db *pgxpool.Pool, bus Publisher, cfg WorkerConfig) *Worker {
 	return &Worker{
-		db:        db,
-		eventBus:  bus,
-		interval:  5 * time.Second,
-		batchSize: 10,
+		db:          db,
+		eventBus:    bus,
+		id:          uuid.New().String(),
+		interval:    cfg.PollInterval,
+		batchSize:   cfg.BatchSize,
+		concurrency: max(cfg.Concurrency, 1),
+		lease:       1 * time.Minute,
+
+		maxRetries:     cfg.MaxRetries,
+		retryBaseDelay: cfg.RetryBaseDelay,
+		retryMaxDelay:  cfg.RetryMaxDelay,
+
+		wake: make(chan struct{}, 1),
 	}
 }
 
 func (w *Worker) Start(ctx context.Context) {
+	go w.listen(ctx)
 	go w.loop(ctx)
 }
 
@@ -43,71 +87,239 @@ func (w *Worker) loop(ctx context.Context) {
 			log.Println("Outbox worker stopped")
 			return
 		case <-ticker.C:
-			w.processBatch(ctx)
+			w.releaseExpiredLeases(ctx)
+			w.drain(ctx)
+		case <-w.wake:
+			w.drain(ctx)
+		}
+	}
+}
+
+func (w *Worker) listen(ctx context.Context) {
+	delay := time.Second
+	for {
+		err := w.waitForNotifications(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		log.Printf("[Outbox] LISTEN connection lost, falling back to polling for %s: %v", delay, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, w.interval)
+	}
+}
+
+func (w *Worker) waitForNotifications(ctx context.Context) error {
+	conn, err := w.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+NotifyChannel); err != nil {
+		return err
+	}
+
+	for {
+		if _, err := conn.Conn().WaitForNotification(ctx); err != nil {
+			return err
+		}
+
+		select {
+		case w.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (w *Worker) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		if w.processBatch(ctx) == 0 {
+			return
 		}
 	}
 }
 
-func (w *Worker) processBatch(ctx context.Context) {
+func (w *Worker) processBatch(ctx context.Context) int {
+	claimed, err := w.claimBatch(ctx)
+	if err != nil {
+		log.Println("Outbox claim error:", err)
+		return 0
+	}
+
+	sem := make(chan struct{}, w.concurrency)
+	var wg sync.WaitGroup
+	for _, event := range claimed {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(event claimedEvent) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			w.processEvent(ctx, event)
+		}(event)
+	}
+	wg.Wait()
+
+	return len(claimed)
+}
+
+// claimBatch holds back every event of an aggregate behind an earlier one
+// that is still unpublished, including one parked as FAILED, so a requeued
+// event is never delivered after its successors.
+func (w *Worker) claimBatch(ctx context.Context) ([]claimedEvent, error) {
 	query := `
-		SELECT id, aggregate_id, event_type, payload
-		FROM outbox_events
-		WHERE status = 'PENDING'
-		ORDER BY created_at ASC
-		LIMIT $1
+		UPDATE outbox_events
+		SET status = 'PROCESSING',
+			locked_by = $2,
+			locked_until = NOW() + make_interval(secs => $3)
+		WHERE id IN (
+			SELECT e.id
+			FROM outbox_events e
+			WHERE e.status = 'PENDING'
+				AND e.next_attempt_at <= NOW()
+				AND NOT EXISTS (
+					SELECT 1
+					FROM outbox_events prev
+					WHERE prev.aggregate_id = e.aggregate_id
+						AND prev.status IN ('PENDING', 'PROCESSING', 'FAILED')
+						AND prev.seq < e.seq
+				)
+			ORDER BY e.seq ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, aggregate_id, correlation_id, event_type, schema_version, payload, retries, seq, created_at
 	`
 
-	rows, err := w.db.Query(ctx, query, w.batchSize)
+	rows, err := w.db.Query(ctx, query, w.batchSize, w.id, w.lease.Seconds())
 	if err != nil {
-		log.Println("Outbox query error:", err)
-		return
+		return nil, err
 	}
 	defer rows.Close()
 
+	var claimed []claimedEvent
 	for rows.Next() {
-		var id string
-		var aggregateID string
-		var eventType string
-		var payload []byte
-
-		err := rows.Scan(&id, &aggregateID, &eventType, &payload)
+		var event claimedEvent
+		err := rows.Scan(
+			&event.id,
+			&event.aggregateID,
+			&event.correlationID,
+			&event.eventType,
+			&event.schemaVersion,
+			&event.payload,
+			&event.retries,
+			&event.seq,
+			&event.createdAt,
+		)
 		if err != nil {
-			continue
+			return nil, err
 		}
+		claimed = append(claimed, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-		w.processEvent(ctx, id, eventType, payload)
+	sort.Slice(claimed, func(i, j int) bool {
+		return claimed[i].seq < claimed[j].seq
+	})
+
+	return claimed, nil
+}
+
+func (w *Worker) releaseExpiredLeases(ctx context.Context) {
+	tag, err := w.db.Exec(ctx, `
+		UPDATE outbox_events
+		SET status = 'PENDING', locked_by = NULL, locked_until = NULL
+		WHERE status = 'PROCESSING' AND locked_until < NOW()
+	`)
+	if err != nil {
+		log.Println("Outbox lease release error:", err)
+		return
+	}
+
+	if n := tag.RowsAffected(); n > 0 {
+		log.Printf("[Outbox] Released %d events with expired leases", n)
 	}
 }
 
-func (w *Worker) processEvent(ctx context.Context, id, eventType string, payload []byte) {
-	var rawData map[string]any
-	json.Unmarshal(payload, &rawData)
+func (w *Worker) processEvent(ctx context.Context, event claimedEvent) {
+	correlationID := event.aggregateID
+	if event.correlationID != nil {
+		correlationID = *event.correlationID
+	}
+
+	meta := eventbus.Metadata{
+		MessageID:     event.id,
+		CorrelationID: correlationID,
+		AggregateID:   event.aggregateID,
+		SchemaVersion: event.schemaVersion,
+		Timestamp:     event.createdAt.UTC(),
+	}
 
 	wrappedEvent := map[string]any{
-		"eventType": eventType,
-		"data":      rawData,
+		"eventId":       meta.MessageID,
+		"eventType":     event.eventType,
+		"schemaVersion": meta.SchemaVersion,
+		"aggregateId":   meta.AggregateID,
+		"correlationId": meta.CorrelationID,
+		"occurredAt":    meta.Timestamp,
+		"data":          json.RawMessage(event.payload),
 	}
 
-	err := w.eventBus.Publish(ctx, eventType, wrappedEvent)
+	err := w.eventBus.PublishWithMetadata(ctx, event.eventType, wrappedEvent, meta)
 	if err != nil {
 		log.Println("Publish failed:", err)
-		w.markFailed(ctx, id)
+		w.markFailed(ctx, event, err)
 		return
 	}
 
-	w.markProcessed(ctx, id)
+	w.markProcessed(ctx, event.id)
 }
 
 func (w *Worker) markProcessed(ctx context.Context, id string) {
 	_, _ = w.db.Exec(ctx,
-		`UPDATE outbox_events SET status='PROCESSED', published_at=NOW() WHERE id=$1`,
-		id,
+		`UPDATE outbox_events SET status='PROCESSED', published_at=NOW(), locked_by=NULL, locked_until=NULL WHERE id=$1 AND locked_by=$2`,
+		id, w.id,
 	)
 }
 
-func (w *Worker) markFailed(ctx context.Context, id string) {
-	_, _ = w.db.Exec(ctx,
-		`UPDATE outbox_events SET status='FAILED' WHERE id=$1`,
-		id,
-	)
+func (w *Worker) markFailed(ctx context.Context, event claimedEvent, cause error) {
+	retries := event.retries + 1
+
+	status := "PENDING"
+	if retries >= w.maxRetries {
+		status = "FAILED"
+		log.Printf("[Outbox] Event %s parked as FAILED after %d attempts: %v", event.id, retries, cause)
+	}
+
+	_, err := w.db.Exec(ctx, `
+		UPDATE outbox_events
+		SET status = $3,
+			retries = $4,
+			error = $5,
+			next_attempt_at = NOW() + make_interval(secs => $6),
+			locked_by = NULL,
+			locked_until = NULL
+		WHERE id = $1 AND locked_by = $2
+	`, event.id, w.id, status, retries, cause.Error(), w.backoff(retries).Seconds())
+	if err != nil {
+		log.Printf("[Outbox] Failed to record failure for event %s: %v", event.id, err)
+	}
+}
+
+func (w *Worker) backoff(retries int) time.Duration {
+	delay := w.retryBaseDelay
+	for i := 1; i < retries; i++ {
+		delay *= 2
+		if delay >= w.retryMaxDelay {
+			return w.retryMaxDelay
+		}
+	}
+	return min(delay, w.retryMaxDelay)
 }
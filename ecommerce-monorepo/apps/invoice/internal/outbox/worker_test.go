@@ -0,0 +1,191 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tomarrohitt/invoice-go/internal/eventbus"
+	"github.com/tomarrohitt/invoice-go/internal/testdb"
+)
+
+type recordingPublisher struct {
+	mu          sync.Mutex
+	counts      map[string]int
+	byAggregate map[string][]string
+}
+
+func (p *recordingPublisher) PublishWithMetadata(ctx context.Context, routingKey string, event any, meta eventbus.Metadata) error {
+	time.Sleep(time.Millisecond)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.counts[meta.MessageID]++
+	p.byAggregate[meta.AggregateID] = append(p.byAggregate[meta.AggregateID], meta.MessageID)
+	return nil
+}
+
+func TestConcurrentWorkersPublishEachEventOnce(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	repo := NewRepository(db)
+
+	const (
+		aggregates   = 20
+		perAggregate = 15
+		workers      = 6
+	)
+
+	expected := make(map[string][]string)
+	for i := 0; i < perAggregate; i++ {
+		for a := 0; a < aggregates; a++ {
+			aggregateID := fmt.Sprintf("order-%02d", a)
+
+			tx, err := db.Begin(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := repo.InsertEvent(ctx, tx, aggregateID, "invoice.generated", map[string]int{"n": i}); err != nil {
+				t.Fatal(err)
+			}
+			if err := tx.Commit(ctx); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	rows, err := db.Query(ctx, `SELECT id, aggregate_id FROM outbox_events ORDER BY seq`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		var id, aggregateID string
+		if err := rows.Scan(&id, &aggregateID); err != nil {
+			t.Fatal(err)
+		}
+		expected[aggregateID] = append(expected[aggregateID], id)
+	}
+	rows.Close()
+
+	pub := &recordingPublisher{
+		counts:      make(map[string]int),
+		byAggregate: make(map[string][]string),
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		w := NewWorker(db, pub, WorkerConfig{
+			PollInterval:   20 * time.Millisecond,
+			BatchSize:      7,
+			Concurrency:    4,
+			MaxRetries:     3,
+			RetryBaseDelay: time.Second,
+			RetryMaxDelay:  time.Second,
+		})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for runCtx.Err() == nil {
+				w.drain(runCtx)
+				if n, err := unpublished(runCtx, db); err == nil && n == 0 {
+					return
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		}()
+	}
+	wg.Wait()
+
+	n, err := unpublished(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Fatalf("%d events still unpublished", n)
+	}
+
+	total := aggregates * perAggregate
+	if len(pub.counts) != total {
+		t.Fatalf("published %d distinct events, want %d", len(pub.counts), total)
+	}
+	for id, n := range pub.counts {
+		if n != 1 {
+			t.Errorf("event %s published %d times", id, n)
+		}
+	}
+	for aggregateID, want := range expected {
+		got := pub.byAggregate[aggregateID]
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("aggregate %s published out of order:\n got %v\nwant %v", aggregateID, got, want)
+		}
+	}
+}
+
+func TestFailedEventHoldsBackItsAggregate(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	repo := NewRepository(db)
+
+	for i := 0; i < 2; i++ {
+		tx, err := db.Begin(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.InsertEvent(ctx, tx, "order-failed", "invoice.generated", map[string]int{"n": i}); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Commit(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var first, second string
+	if err := db.QueryRow(ctx, `SELECT id FROM outbox_events ORDER BY seq LIMIT 1`).Scan(&first); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.QueryRow(ctx, `SELECT id FROM outbox_events ORDER BY seq DESC LIMIT 1`).Scan(&second); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(ctx, `UPDATE outbox_events SET status = 'FAILED' WHERE id = $1`, first); err != nil {
+		t.Fatal(err)
+	}
+
+	pub := &recordingPublisher{
+		counts:      make(map[string]int),
+		byAggregate: make(map[string][]string),
+	}
+	w := NewWorker(db, pub, WorkerConfig{
+		PollInterval:   20 * time.Millisecond,
+		BatchSize:      10,
+		Concurrency:    1,
+		MaxRetries:     3,
+		RetryBaseDelay: time.Second,
+		RetryMaxDelay:  time.Second,
+	})
+
+	w.drain(ctx)
+	if got := pub.byAggregate["order-failed"]; len(got) != 0 {
+		t.Fatalf("published %v while an earlier event is FAILED", got)
+	}
+
+	if _, err := repo.RequeueEvents(ctx, []string{first}); err != nil {
+		t.Fatal(err)
+	}
+	w.drain(ctx)
+
+	if got, want := fmt.Sprint(pub.byAggregate["order-failed"]), fmt.Sprint([]string{first, second}); got != want {
+		t.Fatalf("published %s, want %s", got, want)
+	}
+}
+
+func unpublished(ctx context.Context, db *pgxpool.Pool) (int, error) {
+	var n int
+	err := db.QueryRow(ctx, `SELECT COUNT(*) FROM outbox_events WHERE status <> 'PROCESSED'`).Scan(&n)
+	return n, err
+}
@@ -0,0 +1,88 @@
+package testdb
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const EnvURL = "TEST_DATABASE_URL"
+
+// Open migrates a throwaway schema in the database at TEST_DATABASE_URL and
+// returns a pool scoped to it. The test is skipped when the variable is unset.
+func Open(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	base := os.Getenv(EnvURL)
+	if base == "" {
+		t.Skipf("%s is not set", EnvURL)
+	}
+
+	ctx := context.Background()
+	admin, err := pgx.Connect(ctx, base)
+	if err != nil {
+		t.Fatalf("connect to test database: %v", err)
+	}
+
+	schema := "test_" + strings.ReplaceAll(uuid.New().String(), "-", "")
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		admin.Close(ctx)
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE"); err != nil {
+			t.Logf("drop schema %s: %v", schema, err)
+		}
+		admin.Close(context.Background())
+	})
+
+	scoped, err := withSearchPath(base, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := migrate.New("file://"+migrationsDir(), scoped)
+	if err != nil {
+		t.Fatalf("create migrate instance: %v", err)
+	}
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		t.Fatalf("run migrations: %v", err)
+	}
+	m.Close()
+
+	pool, err := pgxpool.New(ctx, scoped)
+	if err != nil {
+		t.Fatalf("open pool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	return pool
+}
+
+func withSearchPath(rawURL, schema string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme == "" {
+		return "", fmt.Errorf("%s must be a postgres:// URL", EnvURL)
+	}
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func migrationsDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "migrations")
+}
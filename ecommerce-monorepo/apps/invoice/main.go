@@ -9,13 +9,16 @@ import (
 	"strconv"
 	"syscall"
 	"time"
+	_ "time/tzdata"
 
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/tomarrohitt/invoice-go/internal/admin"
 	"github.com/tomarrohitt/invoice-go/internal/config"
 	"github.com/tomarrohitt/invoice-go/internal/database"
 	"github.com/tomarrohitt/invoice-go/internal/eventbus"
+	"github.com/tomarrohitt/invoice-go/internal/inbox"
 	"github.com/tomarrohitt/invoice-go/internal/invoice"
 	"github.com/tomarrohitt/invoice-go/internal/outbox"
 	s3svc "github.com/tomarrohitt/invoice-go/internal/s3"
@@ -30,6 +33,38 @@ func main() {
 		log.Fatalf("Config error: %v", err)
 	}
 
+	branding, err := invoice.LoadBranding(cfg.BrandingFile, invoice.Branding{
+		LegalName:    cfg.BrandLegalName,
+		Address:      cfg.BrandAddress,
+		TaxID:        cfg.BrandTaxID,
+		GSTIN:        cfg.BrandGSTIN,
+		Phone:        cfg.BrandPhone,
+		Email:        cfg.BrandEmail,
+		Website:      cfg.BrandWebsite,
+		LogoPath:     cfg.BrandLogoPath,
+		PrimaryColor: cfg.BrandPrimaryColor,
+		TextColor:    cfg.BrandTextColor,
+		FillColor:    cfg.BrandFillColor,
+		FooterText:   cfg.BrandFooterText,
+	})
+	if err != nil {
+		log.Fatalf("Branding error: %v", err)
+	}
+
+	fonts, err := invoice.LoadFonts(invoice.FontConfig{
+		Dir:           cfg.FontDir,
+		DefaultFamily: cfg.FontDefaultFamily,
+		Locales:       cfg.FontLocaleFamilies,
+	})
+	if err != nil {
+		log.Fatalf("Font error: %v", err)
+	}
+
+	catalogs, err := invoice.LoadCatalogs(fonts)
+	if err != nil {
+		log.Fatalf("Catalog error: %v", err)
+	}
+
 	if err := database.RunMigrations(cfg.DatabaseURL); err != nil {
 		log.Fatalf("Migration error: %v", err)
 	}
@@ -54,7 +89,25 @@ func main() {
 	}
 	defer bus.Close()
 
-	outbox.NewWorker(db, bus).Start(ctx)
+	outboxCfg := outbox.WorkerConfig{
+		PollInterval:   cfg.OutboxPollInterval,
+		BatchSize:      cfg.OutboxBatchSize,
+		Concurrency:    cfg.OutboxConcurrency,
+		MaxRetries:     cfg.OutboxMaxRetries,
+		RetryBaseDelay: cfg.OutboxRetryBaseDelay,
+		RetryMaxDelay:  cfg.OutboxRetryMaxDelay,
+	}
+
+	outbox.NewWorker(db, bus, outboxCfg).Start(ctx)
+
+	retentionCfg := outbox.RetentionConfig{
+		MaxAge:    cfg.OutboxRetention,
+		Interval:  cfg.OutboxRetentionInterval,
+		BatchSize: cfg.OutboxRetentionBatchSize,
+		Archive:   cfg.OutboxArchive,
+	}
+
+	outbox.NewRetentionJob(db, retentionCfg).Start(ctx)
 
 	awsCfg, err := awsconfig.LoadDefaultConfig(ctx,
 		awsconfig.WithRegion(cfg.AWSRegion),
@@ -72,12 +125,36 @@ func main() {
 
 	s3Service := s3svc.NewService(s3Client, cfg.AWSBucket)
 
-	invoiceRepo := invoice.NewRepository(db)
-	outboxRepo := outbox.NewRepository()
+	numbering := invoice.NumberingConfig{
+		Prefix:               cfg.InvoiceNumberPrefix,
+		CreditNotePrefix:     cfg.CreditNotePrefix,
+		FiscalYearStartMonth: cfg.FiscalYearStartMonth,
+		Location:             cfg.BusinessTimezone,
+	}
+
+	invoiceRepo := invoice.NewRepository(db, numbering)
+	outboxRepo := outbox.NewRepository(db)
+	inboxRepo := inbox.NewRepository(db)
+
+	consumer := invoice.NewConsumer(invoiceRepo, outboxRepo, inboxRepo, s3Service, invoice.NewPDFGenerator(branding, fonts, catalogs))
+
+	retryPolicy := eventbus.RetryPolicy{
+		MaxRetries: cfg.SubscribeMaxRetries,
+		BaseDelay:  cfg.SubscribeRetryBaseDelay,
+		MaxDelay:   cfg.SubscribeRetryMaxDelay,
+	}
+
+	err = bus.Subscribe("invoice_service_processor", []string{"order.paid"}, consumer.HandleOrderPaid, retryPolicy)
+	if err != nil {
+		log.Fatalf("Failed to subscribe to events: %v", err)
+	}
 
-	consumer := invoice.NewConsumer(invoiceRepo, outboxRepo, s3Service)
+	err = bus.Subscribe("invoice_service_cancellations", []string{"order.cancelled"}, consumer.HandleOrderCancelled, retryPolicy)
+	if err != nil {
+		log.Fatalf("Failed to subscribe to events: %v", err)
+	}
 
-	err = bus.Subscribe("invoice_service_processor", []string{"order.paid"}, consumer.HandleOrderPaid)
+	err = bus.Subscribe("invoice_service_refunds", []string{"order.refunded"}, consumer.HandleOrderRefunded, retryPolicy)
 	if err != nil {
 		log.Fatalf("Failed to subscribe to events: %v", err)
 	}
@@ -88,6 +165,20 @@ func main() {
 
 	mux.HandleFunc("/api/invoice/download/", handler.DownloadInvoice)
 
+	if cfg.AdminAPIToken != "" {
+		outboxHandler := outbox.NewHandler(outboxRepo)
+
+		adminMux := http.NewServeMux()
+		adminMux.HandleFunc("GET /api/invoice/admin/outbox", outboxHandler.ListEvents)
+		adminMux.HandleFunc("GET /api/invoice/admin/outbox/{id}", outboxHandler.GetEvent)
+		adminMux.HandleFunc("POST /api/invoice/admin/outbox/{id}/requeue", outboxHandler.RequeueEvent)
+		adminMux.HandleFunc("POST /api/invoice/admin/outbox/requeue", outboxHandler.RequeueEvents)
+
+		mux.Handle("/api/invoice/admin/", admin.RequireToken(cfg.AdminAPIToken, adminMux))
+	} else {
+		log.Println("ADMIN_API_TOKEN not set, admin endpoints disabled")
+	}
+
 	mux.HandleFunc("/api/invoice/health", func(w http.ResponseWriter, r *http.Request) {
 		if err := db.Ping(r.Context()); err != nil {
 			log.Printf("[Health] Postgres ping failed: %v", err)
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_outbox_processing_lease;
+
+ALTER TABLE outbox_events
+  DROP COLUMN IF EXISTS locked_until,
+  DROP COLUMN IF EXISTS locked_by;
@@ -0,0 +1,7 @@
+ALTER TABLE outbox_events
+  ADD COLUMN locked_by TEXT,
+  ADD COLUMN locked_until TIMESTAMPTZ;
+
+CREATE INDEX idx_outbox_processing_lease
+ON outbox_events (locked_until)
+WHERE status = 'PROCESSING';
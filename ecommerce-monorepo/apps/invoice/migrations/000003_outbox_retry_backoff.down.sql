@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_outbox_status_next_attempt;
+
+CREATE INDEX idx_outbox_status_created
+ON outbox_events (status, created_at);
+
+ALTER TABLE outbox_events
+  DROP COLUMN IF EXISTS next_attempt_at;
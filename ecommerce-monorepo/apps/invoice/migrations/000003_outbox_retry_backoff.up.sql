@@ -0,0 +1,7 @@
+ALTER TABLE outbox_events
+  ADD COLUMN next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+DROP INDEX IF EXISTS idx_outbox_status_created;
+
+CREATE INDEX idx_outbox_status_next_attempt
+ON outbox_events (status, next_attempt_at, created_at);
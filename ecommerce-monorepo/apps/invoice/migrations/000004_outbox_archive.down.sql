@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_outbox_processed_published;
+DROP TABLE IF EXISTS outbox_events_archive;
@@ -0,0 +1,20 @@
+CREATE TABLE outbox_events_archive (
+  id UUID PRIMARY KEY,
+  aggregate_id TEXT NOT NULL,
+  event_type TEXT NOT NULL,
+  payload JSONB NOT NULL,
+
+  created_at TIMESTAMPTZ NOT NULL,
+  published_at TIMESTAMPTZ,
+  retries INT NOT NULL DEFAULT 0,
+  error TEXT,
+
+  archived_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_outbox_archive_aggregate
+ON outbox_events_archive (aggregate_id);
+
+CREATE INDEX idx_outbox_processed_published
+ON outbox_events (published_at)
+WHERE status = 'PROCESSED';
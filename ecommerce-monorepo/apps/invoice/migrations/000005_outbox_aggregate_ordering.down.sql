@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_outbox_aggregate_inflight;
+
+ALTER TABLE outbox_events
+  DROP COLUMN IF EXISTS seq;
@@ -0,0 +1,6 @@
+ALTER TABLE outbox_events
+  ADD COLUMN seq BIGSERIAL;
+
+CREATE INDEX idx_outbox_aggregate_inflight
+ON outbox_events (aggregate_id, seq)
+WHERE status IN ('PENDING', 'PROCESSING');
@@ -0,0 +1,7 @@
+ALTER TABLE outbox_events_archive
+  DROP COLUMN IF EXISTS schema_version,
+  DROP COLUMN IF EXISTS correlation_id;
+
+ALTER TABLE outbox_events
+  DROP COLUMN IF EXISTS schema_version,
+  DROP COLUMN IF EXISTS correlation_id;
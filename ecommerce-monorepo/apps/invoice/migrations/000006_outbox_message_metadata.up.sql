@@ -0,0 +1,7 @@
+ALTER TABLE outbox_events
+  ADD COLUMN correlation_id TEXT,
+  ADD COLUMN schema_version INT NOT NULL DEFAULT 1;
+
+ALTER TABLE outbox_events_archive
+  ADD COLUMN correlation_id TEXT,
+  ADD COLUMN schema_version INT NOT NULL DEFAULT 1;
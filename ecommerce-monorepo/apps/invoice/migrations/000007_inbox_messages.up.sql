@@ -0,0 +1,16 @@
+CREATE TABLE inbox_messages (
+  message_id TEXT PRIMARY KEY,
+  event_type TEXT NOT NULL,
+  status TEXT NOT NULL DEFAULT 'PROCESSING' CHECK (status IN ('PROCESSING', 'COMPLETED')),
+  attempts INT NOT NULL DEFAULT 1,
+  locked_until TIMESTAMPTZ,
+
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  processed_at TIMESTAMPTZ
+);
+
+CREATE TRIGGER update_inbox_updated_at
+BEFORE UPDATE ON inbox_messages
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,22 @@
+CREATE TABLE credit_notes (
+  id TEXT PRIMARY KEY,
+  invoice_id TEXT NOT NULL REFERENCES invoices(id),
+  order_id TEXT NOT NULL,
+  refund_id TEXT UNIQUE NOT NULL,
+  user_id TEXT NOT NULL,
+  amount NUMERIC(12,2) NOT NULL CHECK (amount > 0),
+  reason TEXT,
+  items JSONB NOT NULL DEFAULT '[]',
+  pdf_url TEXT NOT NULL,
+
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_credit_notes_invoice
+ON credit_notes (invoice_id);
+
+CREATE TRIGGER update_credit_notes_updated_at
+BEFORE UPDATE ON credit_notes
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
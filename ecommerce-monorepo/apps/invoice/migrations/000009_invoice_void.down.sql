@@ -0,0 +1,4 @@
+ALTER TABLE invoices
+  DROP COLUMN IF EXISTS voided_at,
+  DROP COLUMN IF EXISTS void_reason,
+  DROP COLUMN IF EXISTS source_event;
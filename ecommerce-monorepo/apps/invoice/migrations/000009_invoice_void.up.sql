@@ -0,0 +1,4 @@
+ALTER TABLE invoices
+  ADD COLUMN source_event JSONB,
+  ADD COLUMN void_reason TEXT,
+  ADD COLUMN voided_at TIMESTAMPTZ;
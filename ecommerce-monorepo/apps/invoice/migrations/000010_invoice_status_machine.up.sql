@@ -0,0 +1,24 @@
+UPDATE invoices SET status = 'GENERATED' WHERE status = 'COMPLETED';
+
+ALTER TABLE invoices
+  ALTER COLUMN status SET DEFAULT 'PENDING',
+  ADD CONSTRAINT invoices_status_check
+    CHECK (status IN ('PENDING', 'GENERATED', 'FAILED', 'VOID', 'CREDITED'));
+
+CREATE TABLE invoice_status_history (
+  id BIGSERIAL PRIMARY KEY,
+  invoice_id TEXT NOT NULL REFERENCES invoices(id),
+  from_status TEXT,
+  to_status TEXT NOT NULL,
+  actor TEXT NOT NULL,
+  reason TEXT,
+
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_invoice_status_history_invoice
+ON invoice_status_history (invoice_id, created_at);
+
+INSERT INTO invoice_status_history (invoice_id, from_status, to_status, actor, reason, created_at)
+SELECT id, NULL, status, 'migration', 'Backfilled initial status', created_at
+FROM invoices;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_invoices_failed;
+
+ALTER TABLE invoices
+  DROP COLUMN IF EXISTS failed_at,
+  DROP COLUMN IF EXISTS failure_reason,
+  DROP COLUMN IF EXISTS attempts,
+  ALTER COLUMN pdf_url DROP DEFAULT;
@@ -0,0 +1,9 @@
+ALTER TABLE invoices
+  ALTER COLUMN pdf_url SET DEFAULT '',
+  ADD COLUMN attempts INT NOT NULL DEFAULT 0,
+  ADD COLUMN failure_reason TEXT,
+  ADD COLUMN failed_at TIMESTAMPTZ;
+
+CREATE INDEX idx_invoices_failed
+ON invoices (failed_at)
+WHERE status = 'FAILED';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS invoice_billing_snapshots;
+DROP TABLE IF EXISTS invoice_items;
@@ -0,0 +1,31 @@
+CREATE TABLE invoice_items (
+  invoice_id TEXT NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+  position INT NOT NULL,
+  product_id TEXT NOT NULL,
+  name TEXT NOT NULL,
+  unit_price NUMERIC(12,2) NOT NULL,
+  quantity INT NOT NULL,
+  line_total NUMERIC(12,2) NOT NULL,
+
+  PRIMARY KEY (invoice_id, position)
+);
+
+CREATE INDEX idx_invoice_items_product
+ON invoice_items (product_id);
+
+CREATE TABLE invoice_billing_snapshots (
+  invoice_id TEXT PRIMARY KEY REFERENCES invoices(id) ON DELETE CASCADE,
+  customer_name TEXT NOT NULL,
+  customer_email TEXT NOT NULL,
+  shipping_address JSONB NOT NULL,
+  billing_address JSONB NOT NULL,
+  subtotal NUMERIC(12,2) NOT NULL,
+  tax_amount NUMERIC(12,2) NOT NULL,
+  payment_id TEXT NOT NULL,
+  currency TEXT NOT NULL DEFAULT 'USD',
+
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_invoice_billing_email
+ON invoice_billing_snapshots (customer_email);
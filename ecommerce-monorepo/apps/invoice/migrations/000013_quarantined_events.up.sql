@@ -0,0 +1,12 @@
+CREATE TABLE quarantined_events (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  message_id TEXT UNIQUE,
+  event_type TEXT NOT NULL,
+  payload JSONB NOT NULL,
+  errors JSONB NOT NULL,
+
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_quarantined_events_type_created
+ON quarantined_events (event_type, created_at);
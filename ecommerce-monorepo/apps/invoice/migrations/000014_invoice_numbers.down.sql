@@ -0,0 +1,8 @@
+ALTER TABLE invoices
+  DROP CONSTRAINT IF EXISTS invoices_invoice_number_key,
+  DROP COLUMN IF EXISTS issued_at,
+  DROP COLUMN IF EXISTS fiscal_year,
+  DROP COLUMN IF EXISTS series,
+  DROP COLUMN IF EXISTS invoice_number;
+
+DROP TABLE IF EXISTS invoice_number_counters;
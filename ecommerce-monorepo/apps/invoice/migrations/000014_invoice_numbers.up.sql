@@ -0,0 +1,16 @@
+CREATE TABLE invoice_number_counters (
+  series TEXT NOT NULL,
+  fiscal_year INT NOT NULL,
+  last_value BIGINT NOT NULL,
+
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (series, fiscal_year)
+);
+
+ALTER TABLE invoices
+  ADD COLUMN invoice_number TEXT,
+  ADD COLUMN series TEXT,
+  ADD COLUMN fiscal_year INT,
+  ADD COLUMN issued_at TIMESTAMPTZ,
+  ADD CONSTRAINT invoices_invoice_number_key UNIQUE (invoice_number);
@@ -0,0 +1,7 @@
+ALTER TABLE credit_notes
+  DROP COLUMN IF EXISTS currency;
+
+DROP INDEX IF EXISTS idx_invoices_currency;
+
+ALTER TABLE invoices
+  DROP COLUMN IF EXISTS currency;
@@ -0,0 +1,18 @@
+ALTER TABLE invoices
+  ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
+
+UPDATE invoices i
+SET currency = s.currency
+FROM invoice_billing_snapshots s
+WHERE s.invoice_id = i.id;
+
+CREATE INDEX idx_invoices_currency
+ON invoices (currency);
+
+ALTER TABLE credit_notes
+  ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
+
+UPDATE credit_notes c
+SET currency = i.currency
+FROM invoices i
+WHERE i.id = c.invoice_id;
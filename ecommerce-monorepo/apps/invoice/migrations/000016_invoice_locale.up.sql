@@ -0,0 +1,2 @@
+ALTER TABLE invoices
+  ADD COLUMN locale TEXT NOT NULL DEFAULT 'en';
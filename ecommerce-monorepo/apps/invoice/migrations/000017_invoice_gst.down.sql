@@ -0,0 +1,12 @@
+ALTER TABLE invoice_billing_snapshots
+  DROP COLUMN IF EXISTS place_of_supply,
+  DROP COLUMN IF EXISTS buyer_gstin,
+  DROP COLUMN IF EXISTS seller_gstin;
+
+ALTER TABLE invoice_items
+  DROP COLUMN IF EXISTS igst_amount,
+  DROP COLUMN IF EXISTS sgst_amount,
+  DROP COLUMN IF EXISTS cgst_amount,
+  DROP COLUMN IF EXISTS taxable_value,
+  DROP COLUMN IF EXISTS tax_rate,
+  DROP COLUMN IF EXISTS hsn_code;
@@ -0,0 +1,15 @@
+ALTER TABLE invoice_items
+  ADD COLUMN hsn_code TEXT NOT NULL DEFAULT '',
+  ADD COLUMN tax_rate NUMERIC(5,2) NOT NULL DEFAULT 0,
+  ADD COLUMN taxable_value NUMERIC(12,2) NOT NULL DEFAULT 0,
+  ADD COLUMN cgst_amount NUMERIC(12,2) NOT NULL DEFAULT 0,
+  ADD COLUMN sgst_amount NUMERIC(12,2) NOT NULL DEFAULT 0,
+  ADD COLUMN igst_amount NUMERIC(12,2) NOT NULL DEFAULT 0;
+
+UPDATE invoice_items
+SET taxable_value = line_total;
+
+ALTER TABLE invoice_billing_snapshots
+  ADD COLUMN seller_gstin TEXT NOT NULL DEFAULT '',
+  ADD COLUMN buyer_gstin TEXT NOT NULL DEFAULT '',
+  ADD COLUMN place_of_supply TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,6 @@
+CREATE TABLE order_cancellations (
+  order_id TEXT PRIMARY KEY,
+  reason TEXT NOT NULL DEFAULT '',
+
+  cancelled_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_outbox_aggregate_inflight;
+
+CREATE INDEX idx_outbox_aggregate_inflight
+ON outbox_events (aggregate_id, seq)
+WHERE status IN ('PENDING', 'PROCESSING', 'FAILED');
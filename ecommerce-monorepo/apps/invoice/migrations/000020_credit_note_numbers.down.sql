@@ -0,0 +1,6 @@
+ALTER TABLE credit_notes
+  DROP CONSTRAINT IF EXISTS credit_notes_credit_note_number_key,
+  DROP COLUMN IF EXISTS issued_at,
+  DROP COLUMN IF EXISTS credit_note_number;
+
+DROP TABLE IF EXISTS credit_note_numbers;
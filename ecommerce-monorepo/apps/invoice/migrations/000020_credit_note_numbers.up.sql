@@ -0,0 +1,14 @@
+CREATE TABLE credit_note_numbers (
+  refund_id TEXT PRIMARY KEY,
+  credit_note_number TEXT NOT NULL UNIQUE,
+  series TEXT NOT NULL,
+  fiscal_year INT NOT NULL,
+  issued_at TIMESTAMPTZ NOT NULL,
+
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE credit_notes
+  ADD COLUMN credit_note_number TEXT,
+  ADD COLUMN issued_at TIMESTAMPTZ,
+  ADD CONSTRAINT credit_notes_credit_note_number_key UNIQUE (credit_note_number);
@@ -0,0 +1,9 @@
+DELETE FROM quarantined_events q
+USING quarantined_events newer
+WHERE q.message_id = newer.message_id
+  AND q.created_at < newer.created_at;
+
+ALTER TABLE quarantined_events
+  DROP CONSTRAINT IF EXISTS quarantined_events_message_payload_key,
+  ADD CONSTRAINT quarantined_events_message_id_key UNIQUE (message_id),
+  DROP COLUMN IF EXISTS payload_hash;
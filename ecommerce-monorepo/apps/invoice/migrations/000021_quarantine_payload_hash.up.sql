@@ -0,0 +1,10 @@
+ALTER TABLE quarantined_events
+  ADD COLUMN payload_hash TEXT;
+
+UPDATE quarantined_events
+SET payload_hash = encode(sha256(payload::text::bytea), 'hex');
+
+ALTER TABLE quarantined_events
+  ALTER COLUMN payload_hash SET NOT NULL,
+  DROP CONSTRAINT quarantined_events_message_id_key,
+  ADD CONSTRAINT quarantined_events_message_payload_key UNIQUE (message_id, payload_hash);
@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/shopspring/decimal"
 	"github.com/tomarrohitt/invoice-go/internal/events"
 )
 
@@ -45,21 +46,16 @@ func main() {
 		event.Data.UserID = uuid.New().String()
 		event.Data.UserEmail = "buyer_" + orderID[:8] + "@example.com"
 		event.Data.UserName = "Test Buyer"
-		event.Data.TotalAmount = 250.00
-		event.Data.Subtotal = 230.00
-		event.Data.TaxedAmount = 20.00
+		event.Data.TotalAmount = decimal.RequireFromString("250.00")
+		event.Data.Subtotal = decimal.RequireFromString("230.00")
+		event.Data.TaxedAmount = decimal.RequireFromString("20.00")
 		event.Data.PaymentID = "pay_" + uuid.New().String()[:8]
 
-		event.Data.Items = []struct {
-			ProductID string  `json:"productId"`
-			Name      string  `json:"name"`
-			Price     float64 `json:"price"`
-			Quantity  int     `json:"quantity"`
-		}{
+		event.Data.Items = []events.OrderItem{
 			{
 				ProductID: uuid.New().String(),
 				Name:      "Mechanical Keyboard",
-				Price:     230.00,
+				Price:     decimal.RequireFromString("230.00"),
 				Quantity:  1,
 			},
 		}